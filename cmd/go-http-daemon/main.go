@@ -1,8 +1,11 @@
 package main
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime"
 	"net/http"
 	"os"
 	"time"
@@ -10,8 +13,11 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/op/go-logging"
 	"github.com/visola/go-http-cli/pkg/daemon"
+	"github.com/visola/go-http-cli/pkg/progress"
 	"github.com/visola/go-http-cli/pkg/request"
+	"github.com/visola/go-http-cli/pkg/secrets"
 	"github.com/visola/go-http-cli/pkg/session"
+	"github.com/visola/go-http-cli/pkg/timeout"
 )
 
 var (
@@ -28,9 +34,17 @@ func main() {
 
 	configureLogging()
 
+	token, tokenErr := daemon.WriteDaemonToken()
+	if tokenErr != nil {
+		panic(tokenErr)
+	}
+
 	server := mux.NewRouter()
+	server.Use(authenticate(token))
 	server.HandleFunc("/", timeFunction("Handshake", handshake)).Methods(http.MethodGet)
 	server.HandleFunc("/request", timeFunction("Execute Request", executeRequest)).Methods(http.MethodPost)
+	server.HandleFunc("/secrets/unlock", timeFunction("Unlock Secrets", unlockSecrets)).Methods(http.MethodPost)
+	server.HandleFunc("/tokens", timeFunction("Set Tokens", setTokens)).Methods(http.MethodPost)
 	server.HandleFunc("/variables", timeFunction("Set Variable", setVariable)).Methods(http.MethodPost)
 
 	log.Debugf("Daemon version %d.%d started and waiting for connections on port %s", daemon.DaemonMajorVersion, daemon.DaemonMinorVersion, daemon.DaemonPort)
@@ -40,7 +54,29 @@ func main() {
 	}
 
 	go checkInteratcion()
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", daemon.DaemonPort), server))
+	log.Fatal(http.ListenAndServe(fmt.Sprintf("%s:%s", daemon.DaemonHost, daemon.DaemonPort), server))
+}
+
+// authenticate only accepts requests with the token from the daemon token file, that only the user
+// can read, and with JSON bodies, which web pages can't send to other sites without asking
+func authenticate(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if subtle.ConstantTimeCompare([]byte(req.Header.Get(daemon.TokenHeader)), []byte(token)) != 1 {
+				log.Errorf("Rejected request to %s without a valid token", req.URL.Path)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); req.Method == http.MethodPost && mediaType != "application/json" {
+				log.Errorf("Rejected request to %s with content type '%s'", req.URL.Path, mediaType)
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				return
+			}
+
+			next.ServeHTTP(w, req)
+		})
+	}
 }
 
 func configureLogging() {
@@ -77,16 +113,36 @@ func executeRequest(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// Headers are sent right away so that progress can be streamed while the request executes
+	w.WriteHeader(http.StatusOK)
+	encoder := json.NewEncoder(w)
+	executionContext.OnProgress = func(progress progress.Progress) {
+		encoder.Encode(daemon.RequestEvent{Progress: &progress})
+		if flusher, canFlush := w.(http.Flusher); canFlush {
+			flusher.Flush()
+		}
+	}
+
 	requestResponses, responseErr := request.ExecuteRequestLoop(executionContext)
 	requestExecution.RequestResponses = requestResponses
 
 	if responseErr != nil {
 		log.Error(responseErr)
 		requestExecution.ErrorMessage = responseErr.Error()
+		errors.As(responseErr, &requestExecution.LoginRequired)
+
+		// Forget a wrong passphrase so that the user is asked again
+		if responseErr == secrets.ErrWrongPassphrase {
+			secrets.Lock()
+		}
+		requestExecution.SecretsLocked = responseErr == secrets.ErrLocked || responseErr == secrets.ErrWrongPassphrase
+
+		if timeoutErr, isTimeout := responseErr.(timeout.Error); isTimeout {
+			requestExecution.Timeout = timeoutErr.Kind
+		}
 	}
 
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(requestExecution)
+	encoder.Encode(daemon.RequestEvent{RequestExecution: requestExecution})
 }
 
 func handshake(w http.ResponseWriter, req *http.Request) {
@@ -101,6 +157,46 @@ func handshake(w http.ResponseWriter, req *http.Request) {
 	json.NewEncoder(w).Encode(handshake)
 }
 
+func setTokens(w http.ResponseWriter, req *http.Request) {
+	lastInteraction = time.Now().UnixNano()
+
+	var setTokensRequest session.SetTokensRequest
+
+	decoder := json.NewDecoder(req.Body)
+	defer req.Body.Close()
+
+	if parseRequestError := decoder.Decode(&setTokensRequest); parseRequestError != nil {
+		log.Error(parseRequestError)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(parseRequestError.Error()))
+		return
+	}
+
+	session.SetTokens(setTokensRequest.Key, setTokensRequest.Tokens)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func unlockSecrets(w http.ResponseWriter, req *http.Request) {
+	lastInteraction = time.Now().UnixNano()
+
+	var unlockRequest secrets.UnlockRequest
+
+	decoder := json.NewDecoder(req.Body)
+	defer req.Body.Close()
+
+	if parseRequestError := decoder.Decode(&unlockRequest); parseRequestError != nil {
+		log.Error(parseRequestError)
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(parseRequestError.Error()))
+		return
+	}
+
+	secrets.Unlock(unlockRequest.Passphrase)
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func setVariable(w http.ResponseWriter, req *http.Request) {
 	lastInteraction = time.Now().UnixNano()
 
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/visola/go-http-cli/pkg/authorization"
+	"github.com/visola/go-http-cli/pkg/daemon"
+	"github.com/visola/go-http-cli/pkg/request"
+	"github.com/visola/go-http-cli/pkg/session"
+)
+
+// login executes the interactive flow for the authorization and sends the tokens to the daemon to be
+// stored with the cache key. Tokens are requested like the request in the execution context is sent.
+func login(auth authorization.Authorization, cacheKey string, executionContext request.ExecutionContext) {
+	client, clientErr := request.NewLoginClient(executionContext.Request, executionContext)
+	if clientErr != nil {
+		color.Red("Error while logging in: %s", clientErr)
+		os.Exit(40)
+	}
+
+	var tokens *authorization.Tokens
+	var loginErr error
+
+	if strings.ToLower(auth.AuthorizationType) == authorization.OAuth2DeviceCodeAuthorizationType {
+		tokens, loginErr = authorization.LoginWithDeviceCode(client, auth, printDeviceCode)
+	} else {
+		tokens, loginErr = authorization.LoginWithAuthorizationCode(client, auth, openBrowser)
+	}
+
+	if loginErr != nil {
+		color.Red("Error while logging in: %s", loginErr)
+		os.Exit(40)
+	}
+
+	setTokensRequest := session.SetTokensRequest{
+		Key:    cacheKey,
+		Tokens: *tokens,
+	}
+
+	if err := daemon.SetTokens(setTokensRequest); err != nil {
+		color.Red("Error while storing tokens: %s", err)
+		os.Exit(40)
+	}
+}
+
+func openBrowser(authorizationURL string) {
+	color.Yellow("Open the following URL in your browser to login:\n%s\n", authorizationURL)
+
+	var command *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		command = exec.Command("open", authorizationURL)
+	case "windows":
+		command = exec.Command("rundll32", "url.dll,FileProtocolHandler", authorizationURL)
+	default:
+		command = exec.Command("xdg-open", authorizationURL)
+	}
+
+	// If it fails, the user can still open the URL manually
+	command.Start()
+}
+
+func printDeviceCode(deviceCode authorization.DeviceCodeResponse) {
+	color.Yellow("To login, visit %s and enter the code: %s\n", deviceCode.VerificationURI, deviceCode.UserCode)
+	if deviceCode.VerificationURIComplete != "" {
+		color.Yellow("Or open: %s\n", deviceCode.VerificationURIComplete)
+	}
+}
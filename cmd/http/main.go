@@ -5,18 +5,40 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 
 	"github.com/fatih/color"
 	"github.com/visola/go-http-cli/pkg/cli"
 	"github.com/visola/go-http-cli/pkg/daemon"
+	"github.com/visola/go-http-cli/pkg/download"
+	"github.com/visola/go-http-cli/pkg/formdata"
 	"github.com/visola/go-http-cli/pkg/model"
+	"github.com/visola/go-http-cli/pkg/netrc"
 	"github.com/visola/go-http-cli/pkg/output"
 	"github.com/visola/go-http-cli/pkg/profile"
+	"github.com/visola/go-http-cli/pkg/proxy"
 	"github.com/visola/go-http-cli/pkg/request"
 	"github.com/visola/go-http-cli/pkg/session"
+	"github.com/visola/go-http-cli/pkg/timeout"
+	"github.com/visola/go-http-cli/pkg/tlsconfig"
+	"github.com/visola/go-http-cli/pkg/upload"
 )
 
+// Each kind of timeout exits with a different code, so that scripts can tell them apart
+var timeoutExitCodes = map[string]int{
+	timeout.ConnectKind:        60,
+	timeout.TLSHandshakeKind:   61,
+	timeout.ResponseHeaderKind: 62,
+	timeout.RequestKind:        63,
+	timeout.MaxTimeKind:        64,
+}
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "secrets" {
+		runSecretsCommand(os.Args[2:])
+		return
+	}
+
 	ensureDaemon()
 
 	options := parseCommandLineArguments()
@@ -34,8 +56,11 @@ func main() {
 		panic(profileError)
 	}
 
+	bodyFile, removeBodyFile := createBodyFile(options)
+	multipart, removeMultipartStdin := createMultipart(options)
+
 	configuredRequest, configureError := request.ConfigureRequest(
-		initializeRequest(options),
+		initializeRequest(options, bodyFile, multipart),
 		&mergedProfile,
 		configureRequestOptions,
 	)
@@ -44,6 +69,10 @@ func main() {
 		panic(configureError)
 	}
 
+	// The standard input is streamed to the daemon, so it can't be sent again
+	configuredRequest.SingleUseBody = (configuredRequest.BodyFile != "" && options.FileToUpload == upload.Stdin) ||
+		(len(configuredRequest.Multipart) > 0 && hasStdinPart(options.Form))
+
 	loadedPostProcessScript := loadPostProcessScript(options, mergedProfile)
 	if loadedPostProcessScript.SourceCode != "" {
 		configuredRequest.PostProcessCode = loadedPostProcessScript
@@ -51,20 +80,33 @@ func main() {
 
 	executionContext := request.ExecutionContext{
 		AllowInsecure:    options.AllowInsecure,
+		Download:         createDownloadOptions(options),
 		FollowLocation:   options.FollowLocation,
 		MaxAddedRequests: options.MaxAddedRequests,
 		MaxRedirect:      options.MaxRedirect,
+		MaxTime:          options.MaxTime,
+		Netrc:            createNetrcOptions(options),
 		ProfileNames:     options.Profiles,
+		ProxyEnvironment: proxy.FromEnvironment(),
 		Request:          *configuredRequest,
+		RequestName:      options.RequestName,
 		Variables:        options.Variables,
 	}
 
-	requestExecution, requestError := daemon.ExecuteRequest(executionContext)
-	if requestError != nil {
-		color.Red("Error while executing request: %s", requestError)
-		os.Exit(10)
+	requestExecution := executeRequest(executionContext)
+	for attempt := 0; requestExecution.SecretsLocked && attempt < maxUnlockAttempts; attempt++ {
+		unlockSecrets(requestExecution.ErrorMessage)
+		requestExecution = executeRequest(executionContext)
 	}
 
+	// The daemon resolves the authorization, so it knows what to login with and where to store tokens
+	if loginRequired := requestExecution.LoginRequired; loginRequired != nil {
+		login(loginRequired.Auth, loginRequired.CacheKey, executionContext)
+		requestExecution = executeRequest(executionContext)
+	}
+
+	removeBodyFile()
+	removeMultipartStdin()
 	printOutput(requestExecution, options)
 }
 
@@ -97,17 +139,162 @@ func ensureDaemon() {
 	}
 }
 
-func initializeRequest(options *cli.CommandLineOptions) request.Request {
+func executeRequest(executionContext request.ExecutionContext) *daemon.RequestExecution {
+	requestExecution, requestError := daemon.ExecuteRequest(executionContext, output.PrintProgress)
+	if requestError != nil {
+		color.Red("Error while executing request: %s", requestError)
+		os.Exit(10)
+	}
+	return requestExecution
+}
+
+// createBodyFile resolves the file to stream as body. The standard input is passed to the daemon
+// through a file that is removed by the returned function.
+func createBodyFile(options *cli.CommandLineOptions) (string, func()) {
+	if options.FileToUpload == "" {
+		return "", func() {}
+	}
+
+	if options.FileToUpload == upload.Stdin {
+		stdinFile, removeStdinFile, stdinErr := upload.PipeStdin()
+		if stdinErr != nil {
+			panic(stdinErr)
+		}
+		return stdinFile, removeStdinFile
+	}
+
+	// The daemon runs in a different directory, paths need to be resolved here
+	absolutePath, pathErr := filepath.Abs(options.FileToUpload)
+	if pathErr != nil {
+		panic(pathErr)
+	}
+	return absolutePath, func() {}
+}
+
+func createDownloadOptions(options *cli.CommandLineOptions) download.Options {
+	// The daemon runs in a different directory, paths need to be resolved here
+	workingDir, workingDirError := os.Getwd()
+	if workingDirError != nil {
+		panic(workingDirError)
+	}
+
+	downloadOptions := download.Options{
+		Directory:  workingDir,
+		RemoteName: options.RemoteName,
+	}
+
+	if options.OutputFile != "" {
+		downloadOptions.File = filepath.Join(workingDir, options.OutputFile)
+		if filepath.IsAbs(options.OutputFile) {
+			downloadOptions.File = options.OutputFile
+		}
+	}
+
+	return downloadOptions
+}
+
+// createMultipart resolves the files in the form fields. The standard input is passed to the daemon
+// through a file that is removed by the returned function.
+func createMultipart(options *cli.CommandLineOptions) ([]formdata.Part, func()) {
+	removeStdinFile := func() {}
+	if len(options.Form) == 0 {
+		return nil, removeStdinFile
+	}
+
+	parts := make([]formdata.Part, len(options.Form))
+	for index, part := range options.Form {
+		if part.File == upload.Stdin {
+			stdinFile, removeFile, stdinErr := upload.PipeStdin()
+			if stdinErr != nil {
+				panic(stdinErr)
+			}
+			part.File = stdinFile
+			removeStdinFile = removeFile
+
+			// The name of the file that stores the standard input changes with the platform
+			if part.FileName == "" {
+				part.FileName = "stdin"
+			}
+		} else if part.File != "" {
+			// The daemon runs in a different directory, paths need to be resolved here
+			absolutePath, pathErr := filepath.Abs(part.File)
+			if pathErr != nil {
+				panic(pathErr)
+			}
+			part.File = absolutePath
+		}
+		parts[index] = part
+	}
+
+	return parts, removeStdinFile
+}
+
+func hasStdinPart(parts []formdata.Part) bool {
+	for _, part := range parts {
+		if part.File == upload.Stdin {
+			return true
+		}
+	}
+	return false
+}
+
+func createNetrcOptions(options *cli.CommandLineOptions) netrc.Options {
+	netrcOptions := netrc.Options{
+		Enabled:  options.Netrc || options.NetrcOptional || options.NetrcFile != "",
+		Optional: options.NetrcOptional,
+	}
+
+	// The daemon runs in a different directory, paths need to be resolved here
+	if options.NetrcFile != "" {
+		absolutePath, pathErr := filepath.Abs(options.NetrcFile)
+		if pathErr != nil {
+			panic(pathErr)
+		}
+		netrcOptions.File = absolutePath
+	}
+
+	return netrcOptions
+}
+
+func initializeRequest(options *cli.CommandLineOptions, bodyFile string, multipart []formdata.Part) request.Request {
 	unconfiguredRequest := request.Request{
-		Body:    options.Body,
-		Headers: options.Headers,
-		Method:  options.Method,
-		URL:     options.URL,
+		Body:        []byte(options.Body),
+		BodyFile:    bodyFile,
+		Headers:     options.Headers,
+		HTTPVersion: options.HTTPVersion,
+		Method:      options.Method,
+		Multipart:   multipart,
+		Proxy:       options.Proxy,
+		Retry:       options.Retry,
+		Timeouts: timeout.Options{
+			Connect:        options.ConnectTimeout,
+			Request:        options.RequestTimeout,
+			ResponseHeader: options.ResponseTimeout,
+			TLSHandshake:   options.TLSTimeout,
+		},
+		URL: options.URL,
+	}
+
+	// The daemon runs in a different directory, paths need to be resolved here
+	workingDir, workingDirError := os.Getwd()
+	if workingDirError != nil {
+		panic(workingDirError)
 	}
 
-	loadBodyError := unconfiguredRequest.LoadBodyFromFile(options.FileToUpload)
-	if loadBodyError != nil {
-		panic(loadBodyError)
+	unconfiguredRequest.TLS = tlsconfig.Options{
+		CAFile:           options.CACert,
+		Cert:             options.Cert,
+		Key:              options.Key,
+		MaxVersion:       options.TLSMaxVersion,
+		MinVersion:       options.TLSMinVersion,
+		PinnedPublicKeys: options.PinnedPublicKeys,
+	}.ResolvePaths(workingDir)
+
+	if options.UnixSocket != "" {
+		unconfiguredRequest.UnixSocket = options.UnixSocket
+		if !filepath.IsAbs(options.UnixSocket) {
+			unconfiguredRequest.UnixSocket = filepath.Join(workingDir, options.UnixSocket)
+		}
 	}
 
 	return unconfiguredRequest
@@ -144,18 +331,29 @@ func printOutput(requestExecution *daemon.RequestExecution, options *cli.Command
 	for _, requestResponse := range requestExecution.RequestResponses {
 		output.PrintRequest(requestResponse.Request)
 		fmt.Println("")
-		output.PrintResponse(requestResponse.Response)
+
+		if requestResponse.Error != "" {
+			color.Red("Error while executing request: %s", requestResponse.Error)
+		} else {
+			output.PrintResponse(requestResponse.Response)
+		}
 		fmt.Println("")
 
+		if requestResponse.RetryReason != "" {
+			color.Yellow("Retrying in %s because of %s\n\n", requestResponse.RetryDelay, requestResponse.RetryReason)
+			continue
+		}
+
 		if requestResponse.Response.StatusCode >= http.StatusBadRequest {
 			failedRequest++
 		}
 
-		if options.OutputFile != "" && requestResponse.Response.Body != "" {
-			outWriteErr := ioutil.WriteFile(options.OutputFile, []byte(requestResponse.Response.Body), 0644)
-			if outWriteErr != nil {
-				color.Red("Error while writing to output file: %s", outWriteErr)
-			}
+		if requestResponse.Response.Download != nil {
+			output.PrintDownload(*requestResponse.Response.Download)
+		}
+
+		if options.Timings {
+			output.PrintTimings(requestResponse.Response.Timings)
 		}
 
 		if requestResponse.PostProcessOutput != "" {
@@ -174,6 +372,9 @@ func printOutput(requestExecution *daemon.RequestExecution, options *cli.Command
 	if requestExecution.ErrorMessage != "" {
 		color.Red("Error while executing request: %s", requestExecution.ErrorMessage)
 		exitCode = 20
+		if timeoutExitCode, isTimeout := timeoutExitCodes[requestExecution.Timeout]; isTimeout {
+			exitCode = timeoutExitCode
+		}
 	}
 
 	if len(requestExecution.RequestResponses) > 1 {
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/visola/go-http-cli/pkg/daemon"
+	"github.com/visola/go-http-cli/pkg/profile"
+	"github.com/visola/go-http-cli/pkg/secrets"
+	"golang.org/x/term"
+)
+
+const (
+	maxUnlockAttempts = 3
+	secretsUsage      = "Usage:\n  http secrets edit +profile\n  http secrets set +profile key=value..."
+)
+
+// runSecretsCommand edits the encrypted secrets file for a profile
+func runSecretsCommand(args []string) {
+	if len(args) < 2 || !strings.HasPrefix(args[1], "+") {
+		color.Red(secretsUsage)
+		os.Exit(1)
+	}
+
+	// Editors need a file, which can't be written to disk with the secrets in plain text
+	editDir := memoryBackedDir()
+	if args[0] == "edit" && editDir == "" {
+		exitOnSecretsError(errors.New("No memory backed directory to edit secrets in, set XDG_RUNTIME_DIR or use 'http secrets set' instead"))
+	}
+
+	profileName := args[1][1:]
+	secretsFile, secretsFileErr := profile.GetSecretsFile(profileName)
+	exitOnSecretsError(secretsFileErr)
+
+	_, statErr := os.Stat(secretsFile)
+	isNew := os.IsNotExist(statErr)
+
+	passphrase := readPassphrase(fmt.Sprintf("Passphrase for %s: ", secretsFile))
+	if isNew && readPassphrase("Confirm passphrase: ") != passphrase {
+		exitOnSecretsError(errors.New("Passphrases don't match"))
+	}
+
+	plainData, readErr := profile.ReadSecrets(profileName, passphrase)
+	exitOnSecretsError(readErr)
+
+	var changeErr error
+	switch args[0] {
+	case "edit":
+		plainData, changeErr = editInEditor(editDir, plainData)
+	case "set":
+		plainData, changeErr = setSecrets(plainData, args[2:])
+	default:
+		color.Red(secretsUsage)
+		os.Exit(1)
+	}
+	exitOnSecretsError(changeErr)
+
+	exitOnSecretsError(profile.WriteSecrets(profileName, passphrase, plainData))
+	color.Green("Secrets saved to %s", secretsFile)
+}
+
+// editInEditor opens the secrets in the user editor. The editor needs a file, so a temporary one is
+// created in the memory backed directory and removed right after.
+func editInEditor(dir string, plainData []byte) ([]byte, error) {
+	tempFile, tempErr := ioutil.TempFile(dir, "secrets-*.yml")
+	if tempErr != nil {
+		return nil, tempErr
+	}
+
+	defer func() {
+		// Overwrite before removing so that the content is not left around
+		ioutil.WriteFile(tempFile.Name(), make([]byte, len(plainData)), 0600)
+		os.Remove(tempFile.Name())
+	}()
+
+	_, writeErr := tempFile.Write(plainData)
+	tempFile.Close()
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	editor := exec.Command(getEditor(), tempFile.Name())
+	editor.Stdin = os.Stdin
+	editor.Stdout = os.Stdout
+	editor.Stderr = os.Stderr
+	if runErr := editor.Run(); runErr != nil {
+		return nil, fmt.Errorf("Error while running editor: %s", runErr)
+	}
+
+	return ioutil.ReadFile(tempFile.Name())
+}
+
+func exitOnSecretsError(err error) {
+	if err != nil {
+		color.Red("Error while editing secrets: %s", err)
+		os.Exit(50)
+	}
+}
+
+func getEditor() string {
+	for _, variable := range []string{"VISUAL", "EDITOR"} {
+		if editor := os.Getenv(variable); editor != "" {
+			return editor
+		}
+	}
+
+	if runtime.GOOS == "windows" {
+		return "notepad"
+	}
+	return "vi"
+}
+
+func memoryBackedDir() string {
+	for _, dir := range []string{os.Getenv("XDG_RUNTIME_DIR"), "/dev/shm"} {
+		if info, statErr := os.Stat(dir); dir != "" && statErr == nil && info.IsDir() {
+			return dir
+		}
+	}
+	return ""
+}
+
+func readPassphrase(prompt string) string {
+	fmt.Fprint(os.Stderr, prompt)
+	passphrase, readErr := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	exitOnSecretsError(readErr)
+
+	if len(passphrase) == 0 {
+		exitOnSecretsError(errors.New("Passphrase must not be empty"))
+	}
+
+	return string(passphrase)
+}
+
+func setSecrets(plainData []byte, keyValuePairs []string) ([]byte, error) {
+	if len(keyValuePairs) == 0 {
+		return nil, fmt.Errorf("Nothing to set\n%s", secretsUsage)
+	}
+
+	for _, keyValuePair := range keyValuePairs {
+		separatorIndex := strings.Index(keyValuePair, "=")
+		if separatorIndex <= 0 {
+			return nil, fmt.Errorf("Error while parsing key value pair '%s'\nShould be an '=' separated key/value, e.g.: auth.password=mySecret", keyValuePair)
+		}
+
+		var setErr error
+		plainData, setErr = profile.SetSecret(plainData, keyValuePair[:separatorIndex], keyValuePair[separatorIndex+1:])
+		if setErr != nil {
+			return nil, setErr
+		}
+	}
+
+	return plainData, nil
+}
+
+// unlockSecrets asks the user for the passphrase and sends it to the daemon
+func unlockSecrets(errorMessage string) {
+	if errorMessage != secrets.ErrLocked.Error() {
+		color.Red(errorMessage)
+	}
+
+	unlockRequest := secrets.UnlockRequest{
+		Passphrase: readPassphrase("Passphrase to unlock secrets: "),
+	}
+
+	exitOnSecretsError(daemon.UnlockSecrets(unlockRequest))
+}
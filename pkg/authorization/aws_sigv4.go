@@ -0,0 +1,186 @@
+package authorization
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/visola/go-http-cli/pkg/model"
+)
+
+const (
+	awsAlgorithm  = "AWS4-HMAC-SHA256"
+	awsDateFormat = "20060102T150405Z"
+
+	// Sent instead of the payload hash when the body is streamed
+	awsUnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// SignAWSV4 signs a request using AWS Signature Version 4 and returns the headers that need to be
+// added to the request
+func SignAWSV4(auth Authorization, toSign RequestToSign) (map[string]string, error) {
+	if validationErr := auth.IsValid(); validationErr != nil {
+		return nil, validationErr
+	}
+
+	amzDate := toSign.Time.UTC().Format(awsDateFormat)
+	date := amzDate[:8]
+	payloadHash := sha256Hex(toSign.Body)
+	if toSign.StreamedBody {
+		payloadHash = awsUnsignedPayload
+	}
+
+	result := map[string]string{
+		"X-Amz-Date": amzDate,
+	}
+
+	if strings.ToLower(auth.Service) == "s3" {
+		result["X-Amz-Content-Sha256"] = payloadHash
+	}
+
+	if auth.SessionToken != "" {
+		result["X-Amz-Security-Token"] = auth.SessionToken
+	}
+
+	headersToSign := make(map[string][]string)
+	for name, values := range toSign.Headers {
+		headersToSign[name] = values
+	}
+	for name, value := range result {
+		headersToSign[name] = []string{value}
+	}
+
+	canonicalHeaders, signedHeaders := canonicalizeAWSHeaders(toSign.URL.Host, headersToSign)
+
+	canonicalRequest := strings.Join([]string{
+		toSign.Method,
+		canonicalAWSURI(toSign.URL, strings.ToLower(auth.Service) != "s3"),
+		canonicalAWSQuery(toSign.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, auth.Region, auth.Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{awsAlgorithm, amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+auth.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, auth.Region)
+	signingKey = hmacSHA256(signingKey, auth.Service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	result["Authorization"] = fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsAlgorithm,
+		auth.AccessKeyID,
+		scope,
+		signedHeaders,
+		signature,
+	)
+
+	return result, nil
+}
+
+// awsURIEncode encodes everything except unreserved characters as required by AWS
+func awsURIEncode(toEncode string, encodeSlash bool) string {
+	var builder strings.Builder
+	for _, b := range []byte(toEncode) {
+		if (b >= 'A' && b <= 'Z') || (b >= 'a' && b <= 'z') || (b >= '0' && b <= '9') ||
+			b == '-' || b == '_' || b == '.' || b == '~' || (b == '/' && !encodeSlash) {
+			builder.WriteByte(b)
+		} else {
+			builder.WriteString(fmt.Sprintf("%%%02X", b))
+		}
+	}
+	return builder.String()
+}
+
+func canonicalizeAWSHeaders(host string, headers map[string][]string) (string, string) {
+	canonical := map[string]string{
+		"host": host,
+	}
+
+	for name, values := range headers {
+		lowerName := strings.ToLower(strings.TrimSpace(name))
+		if lowerName == "authorization" || lowerName == "host" {
+			continue
+		}
+
+		trimmedValues := make([]string, len(values))
+		for index, value := range values {
+			trimmedValues[index] = strings.Join(strings.Fields(value), " ")
+		}
+
+		if existing, exists := canonical[lowerName]; exists {
+			trimmedValues = append([]string{existing}, trimmedValues...)
+		}
+		canonical[lowerName] = strings.Join(trimmedValues, ",")
+	}
+
+	names := make([]string, 0, len(canonical))
+	for name := range canonical {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var builder strings.Builder
+	for _, name := range names {
+		builder.WriteString(name + ":" + canonical[name] + "\n")
+	}
+
+	return builder.String(), strings.Join(names, ";")
+}
+
+func canonicalAWSQuery(requestURL *url.URL) string {
+	params := make([]model.KeyValuePair, 0)
+	for key, values := range requestURL.Query() {
+		for _, value := range values {
+			params = append(params, model.KeyValuePair{Name: awsURIEncode(key, true), Value: awsURIEncode(value, true)})
+		}
+	}
+
+	// Parameters are sorted by encoded name and then by encoded value. Sorting the joined pairs
+	// instead would put "a-b=" before "a=".
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].Name != params[j].Name {
+			return params[i].Name < params[j].Name
+		}
+		return params[i].Value < params[j].Value
+	})
+
+	pairs := make([]string, len(params))
+	for i, param := range params {
+		pairs[i] = param.Name + "=" + param.Value
+	}
+	return strings.Join(pairs, "&")
+}
+
+// canonicalAWSURI encodes the path. All services except S3 require the path to be encoded twice.
+func canonicalAWSURI(requestURL *url.URL, doubleEncode bool) string {
+	path := requestURL.Path
+	if path == "" {
+		path = "/"
+	}
+
+	encoded := awsURIEncode(path, false)
+	if doubleEncode {
+		encoded = awsURIEncode(encoded, false)
+	}
+	return encoded
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,90 @@
+package authorization
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Values from the AWS Signature Version 4 test suite
+var (
+	awsTestAuth = Authorization{
+		AccessKeyID:       "AKIDEXAMPLE",
+		AuthorizationType: AWSSigV4AuthorizationType,
+		Region:            "us-east-1",
+		SecretAccessKey:   "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		Service:           "service",
+	}
+	awsTestTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+)
+
+func TestSignAWSV4(t *testing.T) {
+	t.Run("Signs simple GET", testSignsSimpleGet)
+	t.Run("Signs query in canonical order", testSignsQueryInCanonicalOrder)
+	t.Run("Sorts query by encoded name and then by value", testSortsQueryByNameAndValue)
+	t.Run("Adds session token and payload hash for S3", testAddsSessionTokenAndPayloadHash)
+	t.Run("Doesn't sign streamed body", testDoesNotSignStreamedBody)
+}
+
+func testSignsSimpleGet(t *testing.T) {
+	headers, err := SignAWSV4(awsTestAuth, createAWSRequestToSign("https://example.amazonaws.com/"))
+
+	assert.Nil(t, err, "Should sign request")
+	assert.Equal(t, "20150830T123600Z", headers["X-Amz-Date"], "Should set date header")
+	assert.Equal(
+		t,
+		"AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		headers["Authorization"],
+		"Should calculate signature",
+	)
+}
+
+func testSignsQueryInCanonicalOrder(t *testing.T) {
+	headers, err := SignAWSV4(awsTestAuth, createAWSRequestToSign("https://example.amazonaws.com/?Param2=value2&Param1=value1"))
+
+	assert.Nil(t, err, "Should sign request")
+	assert.Contains(t, headers["Authorization"], "Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500", "Should sort query parameters")
+}
+
+func testSortsQueryByNameAndValue(t *testing.T) {
+	requestURL, _ := url.Parse("https://example.amazonaws.com/?a-b=1&a=2&a=1")
+
+	assert.Equal(t, "a=1&a=2&a-b=1", canonicalAWSQuery(requestURL), "Should sort by name before value")
+}
+
+func testAddsSessionTokenAndPayloadHash(t *testing.T) {
+	auth := awsTestAuth
+	auth.Service = "s3"
+	auth.SessionToken = "session-token"
+
+	headers, err := SignAWSV4(auth, createAWSRequestToSign("http://localhost:9000/bucket/key"))
+
+	assert.Nil(t, err, "Should sign request")
+	assert.Equal(t, "session-token", headers["X-Amz-Security-Token"], "Should send session token")
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", headers["X-Amz-Content-Sha256"], "Should send payload hash")
+	assert.Contains(t, headers["Authorization"], "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token", "Should sign added headers")
+}
+
+func testDoesNotSignStreamedBody(t *testing.T) {
+	auth := awsTestAuth
+	auth.Service = "s3"
+
+	toSign := createAWSRequestToSign("http://localhost:9000/bucket/key")
+	toSign.StreamedBody = true
+	headers, err := SignAWSV4(auth, toSign)
+
+	assert.Nil(t, err, "Should sign request")
+	assert.Equal(t, "UNSIGNED-PAYLOAD", headers["X-Amz-Content-Sha256"], "Should send unsigned payload")
+}
+
+func createAWSRequestToSign(rawURL string) RequestToSign {
+	requestURL, _ := url.Parse(rawURL)
+	return RequestToSign{
+		Headers: map[string][]string{},
+		Method:  "GET",
+		Time:    awsTestTime,
+		URL:     requestURL,
+	}
+}
@@ -0,0 +1,199 @@
+package authorization
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+	"sync"
+)
+
+// DigestChallenge is a challenge sent by the server in the WWW-Authenticate header
+type DigestChallenge struct {
+	Algorithm string
+	Nonce     string
+	Opaque    string
+	Qop       string
+	Realm     string
+	Stale     bool
+}
+
+type digestState struct {
+	challenge  DigestChallenge
+	nonceCount int
+}
+
+var (
+	digestStates = make(map[string]*digestState)
+	digestMutex  = &sync.Mutex{}
+)
+
+// ParseDigestChallenge finds the best digest challenge in the values of WWW-Authenticate headers.
+// SHA-256 is preferred over MD5. Returns nil if no supported challenge is found.
+func ParseDigestChallenge(headerValues []string) *DigestChallenge {
+	var selected *DigestChallenge
+	for _, headerValue := range headerValues {
+		if len(headerValue) < 7 || !strings.EqualFold(headerValue[:7], "digest ") {
+			continue
+		}
+
+		params := parseAuthParams(headerValue[7:])
+		challenge := &DigestChallenge{
+			Algorithm: params["algorithm"],
+			Nonce:     params["nonce"],
+			Opaque:    params["opaque"],
+			Qop:       params["qop"],
+			Realm:     params["realm"],
+			Stale:     strings.EqualFold(params["stale"], "true"),
+		}
+
+		if challenge.Algorithm == "" {
+			challenge.Algorithm = "MD5"
+		}
+
+		if challenge.Nonce == "" || newDigestHash(challenge.Algorithm) == nil {
+			continue
+		}
+
+		if selected == nil || strings.HasPrefix(strings.ToUpper(challenge.Algorithm), "SHA-256") {
+			selected = challenge
+		}
+	}
+	return selected
+}
+
+// SetDigestChallenge stores the challenge received from a host, resetting the nonce count
+func SetDigestChallenge(host string, auth Authorization, challenge DigestChallenge) {
+	digestMutex.Lock()
+	defer digestMutex.Unlock()
+
+	digestStates[digestStateKey(host, auth)] = &digestState{challenge: challenge}
+}
+
+// NextDigestAuthorization calculates the authorization header value for a request using the last
+// challenge received from the host. Returns false if no challenge was received yet.
+func NextDigestAuthorization(host string, auth Authorization, method string, uri string) (string, bool) {
+	digestMutex.Lock()
+	defer digestMutex.Unlock()
+
+	state, exists := digestStates[digestStateKey(host, auth)]
+	if !exists {
+		return "", false
+	}
+
+	state.nonceCount++
+	return CalculateDigestAuthorization(auth, state.challenge, method, uri, state.nonceCount, randomString(16)), true
+}
+
+// CalculateDigestAuthorization calculates the authorization header value as defined in RFC 7616
+func CalculateDigestAuthorization(auth Authorization, challenge DigestChallenge, method string, uri string, nonceCount int, cnonce string) string {
+	algorithm := strings.ToUpper(challenge.Algorithm)
+	hashFunction := func(data string) string {
+		digestHash := newDigestHash(algorithm)
+		digestHash.Write([]byte(data))
+		return hex.EncodeToString(digestHash.Sum(nil))
+	}
+
+	ha1 := hashFunction(strings.Join([]string{auth.Username, challenge.Realm, auth.Password}, ":"))
+	if strings.HasSuffix(algorithm, "-SESS") {
+		ha1 = hashFunction(strings.Join([]string{ha1, challenge.Nonce, cnonce}, ":"))
+	}
+	ha2 := hashFunction(method + ":" + uri)
+
+	useQop := hasQopAuth(challenge.Qop)
+	nc := fmt.Sprintf("%08x", nonceCount)
+
+	var response string
+	if useQop {
+		response = hashFunction(strings.Join([]string{ha1, challenge.Nonce, nc, cnonce, "auth", ha2}, ":"))
+	} else {
+		response = hashFunction(strings.Join([]string{ha1, challenge.Nonce, ha2}, ":"))
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, auth.Username),
+		fmt.Sprintf(`realm="%s"`, challenge.Realm),
+		fmt.Sprintf(`nonce="%s"`, challenge.Nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`algorithm=%s`, challenge.Algorithm),
+	}
+
+	if useQop {
+		parts = append(parts, "qop=auth", "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+
+	parts = append(parts, fmt.Sprintf(`response="%s"`, response))
+
+	if challenge.Opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, challenge.Opaque))
+	}
+
+	return "Digest " + strings.Join(parts, ", ")
+}
+
+func digestStateKey(host string, auth Authorization) string {
+	return host + "|" + auth.Username
+}
+
+func hasQopAuth(qop string) bool {
+	for _, option := range strings.Split(qop, ",") {
+		if strings.TrimSpace(option) == "auth" {
+			return true
+		}
+	}
+	return false
+}
+
+func newDigestHash(algorithm string) hash.Hash {
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		return md5.New()
+	case "SHA-256":
+		return sha256.New()
+	}
+	return nil
+}
+
+// parseAuthParams parses comma separated key=value pairs where values can be quoted strings
+func parseAuthParams(toParse string) map[string]string {
+	result := make(map[string]string)
+	remaining := toParse
+	for {
+		remaining = strings.TrimLeft(remaining, " ,\t")
+		equalIndex := strings.Index(remaining, "=")
+		if equalIndex < 0 {
+			return result
+		}
+
+		key := strings.ToLower(strings.TrimSpace(remaining[:equalIndex]))
+		remaining = strings.TrimLeft(remaining[equalIndex+1:], " \t")
+
+		var value string
+		if strings.HasPrefix(remaining, `"`) {
+			var builder strings.Builder
+			index := 1
+			for ; index < len(remaining) && remaining[index] != '"'; index++ {
+				if remaining[index] == '\\' && index+1 < len(remaining) {
+					index++
+				}
+				builder.WriteByte(remaining[index])
+			}
+			value = builder.String()
+			if index < len(remaining) {
+				index++
+			}
+			remaining = remaining[index:]
+		} else {
+			commaIndex := strings.Index(remaining, ",")
+			if commaIndex < 0 {
+				commaIndex = len(remaining)
+			}
+			value = strings.TrimSpace(remaining[:commaIndex])
+			remaining = remaining[commaIndex:]
+		}
+
+		result[key] = value
+	}
+}
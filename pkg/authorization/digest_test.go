@@ -0,0 +1,73 @@
+package authorization
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Values from the example in RFC 7616 section 3.9.1
+var (
+	rfcAuth = Authorization{
+		AuthorizationType: DigestAuthorizationType,
+		Password:          "Circle of Life",
+		Username:          "Mufasa",
+	}
+	rfcCnonce = "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+)
+
+func TestDigestAuthorization(t *testing.T) {
+	t.Run("Parses challenge preferring SHA-256", testParsesDigestChallenge)
+	t.Run("Calculates MD5 response", testCalculatesMD5DigestResponse)
+	t.Run("Calculates SHA-256 response", testCalculatesSHA256DigestResponse)
+	t.Run("Counts nonce usage", testCountsNonceUsage)
+}
+
+func testParsesDigestChallenge(t *testing.T) {
+	challenge := ParseDigestChallenge([]string{
+		`Basic realm="something"`,
+		`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=SHA-256, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`,
+		`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=MD5, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`,
+	})
+
+	assert.NotNil(t, challenge, "Should parse challenge")
+	if challenge == nil {
+		return
+	}
+
+	assert.Equal(t, "SHA-256", challenge.Algorithm, "Should prefer SHA-256")
+	assert.Equal(t, "http-auth@example.org", challenge.Realm, "Should parse realm")
+	assert.Equal(t, "auth, auth-int", challenge.Qop, "Should parse quoted values")
+	assert.Equal(t, "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS", challenge.Opaque, "Should parse opaque")
+
+	assert.Nil(t, ParseDigestChallenge([]string{`Basic realm="something"`}), "Should ignore other schemes")
+}
+
+func testCalculatesMD5DigestResponse(t *testing.T) {
+	challenge := ParseDigestChallenge([]string{`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=MD5, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`})
+	headerValue := CalculateDigestAuthorization(rfcAuth, *challenge, "GET", "/dir/index.html", 1, rfcCnonce)
+
+	assert.Contains(t, headerValue, `response="8ca523f5e9506fed4657c9700eebdbec"`, "Should calculate MD5 response")
+	assert.Contains(t, headerValue, "nc=00000001", "Should send nonce count")
+	assert.Contains(t, headerValue, "qop=auth,", "Should use qop=auth")
+}
+
+func testCalculatesSHA256DigestResponse(t *testing.T) {
+	challenge := ParseDigestChallenge([]string{`Digest realm="http-auth@example.org", qop="auth, auth-int", algorithm=SHA-256, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`})
+	headerValue := CalculateDigestAuthorization(rfcAuth, *challenge, "GET", "/dir/index.html", 1, rfcCnonce)
+
+	assert.Contains(t, headerValue, `response="753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"`, "Should calculate SHA-256 response")
+}
+
+func testCountsNonceUsage(t *testing.T) {
+	host := "counting.example.org"
+	_, hasChallenge := NextDigestAuthorization(host, rfcAuth, "GET", "/")
+	assert.False(t, hasChallenge, "Should not have challenge before receiving one")
+
+	SetDigestChallenge(host, rfcAuth, DigestChallenge{Algorithm: "MD5", Nonce: "abc", Qop: "auth", Realm: "test"})
+
+	first, _ := NextDigestAuthorization(host, rfcAuth, "GET", "/")
+	second, _ := NextDigestAuthorization(host, rfcAuth, "GET", "/")
+	assert.Contains(t, first, "nc=00000001", "First use should be 1")
+	assert.Contains(t, second, "nc=00000002", "Second use should be 2")
+}
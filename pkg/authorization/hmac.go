@@ -0,0 +1,122 @@
+package authorization
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/visola/variables/variables"
+)
+
+const (
+	defaultHMACAlgorithm   = "sha256"
+	defaultHMACEncoding    = "hex"
+	defaultHMACHeaderValue = "{signature}"
+)
+
+// SignHMAC calculates an HMAC signature over the canonical string template and returns the headers
+// that need to be added to the request
+func SignHMAC(auth Authorization, toSign RequestToSign) (map[string]string, error) {
+	if validationErr := auth.IsValid(); validationErr != nil {
+		return nil, validationErr
+	}
+
+	if toSign.StreamedBody && strings.Contains(auth.CanonicalString, "{bodyHash}") {
+		return nil, errors.New("Can't calculate {bodyHash} for a body streamed from a file")
+	}
+
+	algorithm := coalesce(auth.Algorithm, defaultHMACAlgorithm)
+	encoding := coalesce(auth.Encoding, defaultHMACEncoding)
+
+	bodyHash := newHMACHash(algorithm)()
+	bodyHash.Write([]byte(toSign.Body))
+
+	templateValues := map[string]string{
+		"bodyHash":  encodeHMAC(encoding, bodyHash.Sum(nil)),
+		"date":      toSign.Time.UTC().Format(time.RFC3339),
+		"host":      toSign.URL.Host,
+		"keyId":     auth.KeyID,
+		"method":    toSign.Method,
+		"nonce":     randomString(16),
+		"path":      toSign.URL.EscapedPath(),
+		"query":     toSign.URL.RawQuery,
+		"timestamp": strconv.FormatInt(toSign.Time.Unix(), 10),
+	}
+
+	for name, values := range toSign.Headers {
+		templateValues["header-"+strings.ToLower(name)] = strings.Join(values, ",")
+	}
+
+	// Escaped new lines make it easier to write templates in single line YAML strings
+	canonicalString := strings.Replace(auth.CanonicalString, `\n`, "\n", -1)
+	canonicalString = variables.ReplaceVariables(canonicalString, templateValues)
+
+	mac := hmac.New(newHMACHash(algorithm), []byte(auth.Secret))
+	mac.Write([]byte(canonicalString))
+	templateValues["signature"] = encodeHMAC(encoding, mac.Sum(nil))
+
+	header := coalesce(auth.Header, auth.ToHeaderKey())
+	result := map[string]string{
+		header: variables.ReplaceVariables(coalesce(auth.HeaderValue, defaultHMACHeaderValue), templateValues),
+	}
+
+	if auth.TimestampHeader != "" {
+		result[auth.TimestampHeader] = templateValues["timestamp"]
+	}
+
+	return result, nil
+}
+
+func coalesce(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func encodeHMAC(encoding string, data []byte) string {
+	if strings.ToLower(encoding) == "base64" {
+		return base64.StdEncoding.EncodeToString(data)
+	}
+	return hex.EncodeToString(data)
+}
+
+func newHMACHash(algorithm string) func() hash.Hash {
+	switch strings.ToLower(algorithm) {
+	case "sha1":
+		return sha1.New
+	case "sha256":
+		return sha256.New
+	case "sha512":
+		return sha512.New
+	}
+	return nil
+}
+
+func validateHMAC(auth Authorization) error {
+	if auth.Secret == "" || auth.CanonicalString == "" {
+		return fmt.Errorf("Secret and canonical string must not be empty for HMAC auth")
+	}
+
+	if newHMACHash(coalesce(auth.Algorithm, defaultHMACAlgorithm)) == nil {
+		return fmt.Errorf("Unsupported HMAC algorithm '%s', must be one of: sha1, sha256, sha512", auth.Algorithm)
+	}
+
+	encoding := strings.ToLower(coalesce(auth.Encoding, defaultHMACEncoding))
+	if encoding != "hex" && encoding != "base64" {
+		return fmt.Errorf("Unsupported HMAC encoding '%s', must be one of: hex, base64", auth.Encoding)
+	}
+
+	return nil
+}
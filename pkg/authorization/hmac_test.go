@@ -0,0 +1,98 @@
+package authorization
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignHMAC(t *testing.T) {
+	t.Run("Signs canonical string with request values", testSignsHMACCanonicalString)
+	t.Run("Uses configured algorithm, encoding and header", testSignsHMACWithConfiguredOptions)
+	t.Run("Validates configuration", testValidatesHMAC)
+	t.Run("Fails to hash streamed body", testFailsToHashStreamedBody)
+}
+
+func testSignsHMACCanonicalString(t *testing.T) {
+	auth := Authorization{
+		AuthorizationType: HMACAuthorizationType,
+		CanonicalString:   `{method}\n{path}\n{query}\n{timestamp}\n{bodyHash}\n{header-content-type}`,
+		HeaderValue:       "HMAC {keyId}:{signature}",
+		KeyID:             "my-key",
+		Secret:            "my-secret",
+		TimestampHeader:   "X-Timestamp",
+	}
+
+	headers, err := SignHMAC(auth, createHMACRequestToSign("POST", "http://localhost/orders?a=1", `{"id":1}`))
+
+	assert.Nil(t, err, "Should sign request")
+	assert.Equal(t, "HMAC my-key:c84b14002ab04c2fd9bd3a2a22e6c1ce6a31c66553e22118713c887d856826b2", headers["Authorization"], "Should set signature in authorization header")
+	assert.Equal(t, "1700000000", headers["X-Timestamp"], "Should set timestamp header")
+}
+
+func testSignsHMACWithConfiguredOptions(t *testing.T) {
+	auth := Authorization{
+		Algorithm:         "sha1",
+		AuthorizationType: HMACAuthorizationType,
+		CanonicalString:   "{method} {path}",
+		Encoding:          "base64",
+		Header:            "X-Signature",
+		Secret:            "my-secret",
+	}
+
+	headers, err := SignHMAC(auth, createHMACRequestToSign("GET", "http://localhost/orders", ""))
+
+	assert.Nil(t, err, "Should sign request")
+	assert.Equal(t, map[string]string{"X-Signature": "OqiEjeiSXL8jCk5IOmmQbSj7eLE="}, headers, "Should only set the signature header")
+}
+
+func testValidatesHMAC(t *testing.T) {
+	auth := Authorization{
+		AuthorizationType: HMACAuthorizationType,
+		CanonicalString:   "{method}",
+		Secret:            "my-secret",
+	}
+	assert.Nil(t, auth.IsValid(), "Should be valid with defaults")
+
+	auth.Algorithm = "md5"
+	assert.NotNil(t, auth.IsValid(), "Should not accept unsupported algorithm")
+
+	auth.Algorithm = "sha512"
+	auth.Encoding = "base32"
+	assert.NotNil(t, auth.IsValid(), "Should not accept unsupported encoding")
+
+	auth.Encoding = ""
+	auth.Secret = ""
+	assert.NotNil(t, auth.IsValid(), "Should require secret")
+}
+
+func testFailsToHashStreamedBody(t *testing.T) {
+	auth := Authorization{
+		AuthorizationType: HMACAuthorizationType,
+		CanonicalString:   "{method}\n{bodyHash}",
+		Secret:            "my-secret",
+	}
+
+	toSign := createHMACRequestToSign("PUT", "http://localhost/files", "")
+	toSign.StreamedBody = true
+
+	_, err := SignHMAC(auth, toSign)
+	assert.NotNil(t, err, "Should not sign body it can't hash")
+
+	auth.CanonicalString = "{method}\n{path}"
+	_, err = SignHMAC(auth, toSign)
+	assert.Nil(t, err, "Should sign without body hash")
+}
+
+func createHMACRequestToSign(method string, toParse string, body string) RequestToSign {
+	parsedURL, _ := url.Parse(toParse)
+	return RequestToSign{
+		Body:    body,
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+		Method:  method,
+		Time:    time.Unix(1700000000, 0),
+		URL:     parsedURL,
+	}
+}
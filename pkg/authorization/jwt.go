@@ -0,0 +1,185 @@
+package authorization
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+const (
+	// JWTES256Algorithm signs tokens using ECDSA with the P-256 curve and SHA-256
+	JWTES256Algorithm = "ES256"
+
+	// JWTRS256Algorithm signs tokens using RSA PKCS#1 v1.5 with SHA-256
+	JWTRS256Algorithm = "RS256"
+
+	defaultJWTExpiration = 5 * time.Minute
+)
+
+// SignJWT mints a new JWT signed with the configured private key and returns the Authorization
+// header with it as a Bearer token. The exp claim is a duration relative to the request time.
+func SignJWT(auth Authorization, toSign RequestToSign) (map[string]string, error) {
+	if validationErr := auth.IsValid(); validationErr != nil {
+		return nil, validationErr
+	}
+
+	keyPEM, readErr := ioutil.ReadFile(auth.KeyFile)
+	if readErr != nil {
+		return nil, fmt.Errorf("Error while reading JWT key file: %s", readErr)
+	}
+
+	privateKey, keyErr := parsePrivateKey(keyPEM)
+	if keyErr != nil {
+		return nil, keyErr
+	}
+
+	algorithm, algorithmErr := jwtAlgorithm(auth.Algorithm, privateKey)
+	if algorithmErr != nil {
+		return nil, algorithmErr
+	}
+
+	header := map[string]string{
+		"alg": algorithm,
+		"typ": "JWT",
+	}
+	if auth.KeyID != "" {
+		header["kid"] = auth.KeyID
+	}
+
+	claims, claimsErr := createJWTClaims(auth.Claims, toSign.Time)
+	if claimsErr != nil {
+		return nil, claimsErr
+	}
+
+	headerJSON, _ := json.Marshal(header)
+	claimsJSON, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(headerJSON) + "." + base64.RawURLEncoding.EncodeToString(claimsJSON)
+
+	signature, signErr := signJWT(privateKey, signingInput)
+	if signErr != nil {
+		return nil, signErr
+	}
+
+	return map[string]string{
+		auth.ToHeaderKey(): "Bearer " + signingInput + "." + base64.RawURLEncoding.EncodeToString(signature),
+	}, nil
+}
+
+// createJWTClaims creates the claims from the template, adding iat and jti and converting the
+// relative exp to a timestamp
+func createJWTClaims(template map[string]string, issuedAt time.Time) (map[string]interface{}, error) {
+	claims := make(map[string]interface{})
+	for name, value := range template {
+		claims[name] = value
+	}
+
+	expiration := defaultJWTExpiration
+	if relativeExpiration, hasExpiration := template["exp"]; hasExpiration {
+		var parseErr error
+		if expiration, parseErr = time.ParseDuration(relativeExpiration); parseErr != nil {
+			return nil, fmt.Errorf("JWT exp claim must be a duration, like 5m: %s", parseErr)
+		}
+	}
+
+	claims["exp"] = issuedAt.Add(expiration).Unix()
+	claims["iat"] = issuedAt.Unix()
+	claims["jti"] = randomString(16)
+
+	return claims, nil
+}
+
+// jwtAlgorithm checks that the algorithm matches the key, if no algorithm is configured it's
+// inferred from the key type
+func jwtAlgorithm(configured string, privateKey crypto.Signer) (string, error) {
+	var keyAlgorithm string
+	switch key := privateKey.(type) {
+	case *rsa.PrivateKey:
+		keyAlgorithm = JWTRS256Algorithm
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return "", errors.New("JWT ES256 keys must use the P-256 curve")
+		}
+		keyAlgorithm = JWTES256Algorithm
+	default:
+		return "", errors.New("JWT key must be an RSA or ECDSA private key")
+	}
+
+	if configured != "" && !strings.EqualFold(configured, keyAlgorithm) {
+		return "", fmt.Errorf("JWT algorithm %s doesn't match the key, which requires %s", configured, keyAlgorithm)
+	}
+
+	return keyAlgorithm, nil
+}
+
+// parsePrivateKey parses a PEM encoded RSA or ECDSA private key in PKCS#1, SEC 1 or PKCS#8 format
+func parsePrivateKey(pemData []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil {
+		return nil, errors.New("Private key must be PEM encoded")
+	}
+
+	if rsaKey, rsaErr := x509.ParsePKCS1PrivateKey(block.Bytes); rsaErr == nil {
+		return rsaKey, nil
+	}
+
+	if ecKey, ecErr := x509.ParseECPrivateKey(block.Bytes); ecErr == nil {
+		return ecKey, nil
+	}
+
+	parsedKey, pkcs8Err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if pkcs8Err != nil {
+		return nil, fmt.Errorf("Error while parsing private key: %s", pkcs8Err)
+	}
+
+	signer, isSigner := parsedKey.(crypto.Signer)
+	if !isSigner {
+		return nil, errors.New("Unsupported private key type")
+	}
+
+	return signer, nil
+}
+
+func signJWT(privateKey crypto.Signer, signingInput string) ([]byte, error) {
+	hashed := sha256.Sum256([]byte(signingInput))
+
+	if ecKey, isEC := privateKey.(*ecdsa.PrivateKey); isEC {
+		r, s, signErr := ecdsa.Sign(rand.Reader, ecKey, hashed[:])
+		if signErr != nil {
+			return nil, signErr
+		}
+
+		// JWS uses the fixed size concatenation of r and s instead of ASN.1
+		signature := make([]byte, 64)
+		rBytes, sBytes := r.Bytes(), s.Bytes()
+		copy(signature[32-len(rBytes):32], rBytes)
+		copy(signature[64-len(sBytes):], sBytes)
+		return signature, nil
+	}
+
+	return rsa.SignPKCS1v15(rand.Reader, privateKey.(*rsa.PrivateKey), crypto.SHA256, hashed[:])
+}
+
+func validateJWT(auth Authorization) error {
+	if auth.KeyFile == "" {
+		return errors.New("Key file must not be empty for JWT auth")
+	}
+
+	algorithm := strings.ToUpper(auth.Algorithm)
+	if algorithm != "" && algorithm != JWTRS256Algorithm && algorithm != JWTES256Algorithm {
+		return fmt.Errorf("Unsupported JWT algorithm '%s', must be one of: %s, %s", auth.Algorithm, JWTRS256Algorithm, JWTES256Algorithm)
+	}
+
+	return nil
+}
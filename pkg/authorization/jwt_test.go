@@ -0,0 +1,145 @@
+package authorization
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignJWT(t *testing.T) {
+	t.Run("Mints token signed with RS256", testMintsJWTWithRS256)
+	t.Run("Mints token signed with ES256", testMintsJWTWithES256)
+	t.Run("Fails if algorithm doesn't match key", testFailsIfJWTAlgorithmDoesNotMatchKey)
+	t.Run("Validates configuration", testValidatesJWT)
+}
+
+func testMintsJWTWithRS256(t *testing.T) {
+	privateKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keyFile := writeTestKey(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(privateKey))
+	defer os.RemoveAll(filepath.Dir(keyFile))
+
+	auth := Authorization{
+		AuthorizationType: JWTAuthorizationType,
+		Claims: map[string]string{
+			"aud": "https://api.example.com",
+			"exp": "10m",
+			"iss": "my-service",
+		},
+		KeyFile: keyFile,
+		KeyID:   "my-key",
+	}
+
+	headers, err := SignJWT(auth, RequestToSign{Time: time.Unix(1700000000, 0)})
+	require.Nil(t, err, "Should mint token")
+
+	header, claims, signingInput, signature := parseTestJWT(t, headers["Authorization"])
+	assert.Equal(t, map[string]interface{}{"alg": "RS256", "kid": "my-key", "typ": "JWT"}, header, "Should set header")
+	assert.Equal(t, "my-service", claims["iss"], "Should set claims from template")
+	assert.Equal(t, "https://api.example.com", claims["aud"], "Should set claims from template")
+	assert.Equal(t, float64(1700000000), claims["iat"], "Should set issued at")
+	assert.Equal(t, float64(1700000600), claims["exp"], "Should set expiration relative to issued at")
+	assert.NotEmpty(t, claims["jti"], "Should set a unique ID")
+
+	hashed := sha256.Sum256([]byte(signingInput))
+	assert.Nil(t, rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA256, hashed[:], signature), "Should sign with the private key")
+}
+
+func testMintsJWTWithES256(t *testing.T) {
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	pkcs8Key, _ := x509.MarshalPKCS8PrivateKey(privateKey)
+	keyFile := writeTestKey(t, "PRIVATE KEY", pkcs8Key)
+	defer os.RemoveAll(filepath.Dir(keyFile))
+
+	auth := Authorization{
+		Algorithm:         "ES256",
+		AuthorizationType: JWTAuthorizationType,
+		KeyFile:           keyFile,
+	}
+
+	headers, err := SignJWT(auth, RequestToSign{Time: time.Unix(1700000000, 0)})
+	require.Nil(t, err, "Should mint token")
+
+	header, claims, signingInput, signature := parseTestJWT(t, headers["Authorization"])
+	assert.Equal(t, "ES256", header["alg"], "Should set algorithm")
+	assert.NotContains(t, header, "kid", "Should not set key ID if not configured")
+	assert.Equal(t, float64(1700000300), claims["exp"], "Should expire in 5 minutes by default")
+
+	require.Equal(t, 64, len(signature), "Should concatenate r and s")
+	hashed := sha256.Sum256([]byte(signingInput))
+	r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+	assert.True(t, ecdsa.Verify(&privateKey.PublicKey, hashed[:], r, s), "Should sign with the private key")
+}
+
+func testFailsIfJWTAlgorithmDoesNotMatchKey(t *testing.T) {
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecKey, _ := x509.MarshalECPrivateKey(privateKey)
+	keyFile := writeTestKey(t, "EC PRIVATE KEY", ecKey)
+	defer os.RemoveAll(filepath.Dir(keyFile))
+
+	auth := Authorization{
+		Algorithm:         "RS256",
+		AuthorizationType: JWTAuthorizationType,
+		KeyFile:           keyFile,
+	}
+
+	_, err := SignJWT(auth, RequestToSign{Time: time.Now()})
+	assert.NotNil(t, err, "Should not sign RS256 with an EC key")
+}
+
+func testValidatesJWT(t *testing.T) {
+	auth := Authorization{
+		AuthorizationType: JWTAuthorizationType,
+		KeyFile:           "key.pem",
+	}
+	assert.Nil(t, auth.IsValid(), "Should be valid with defaults")
+
+	auth.Algorithm = "HS256"
+	assert.NotNil(t, auth.IsValid(), "Should not accept unsupported algorithm")
+
+	auth.Algorithm = ""
+	auth.KeyFile = ""
+	assert.NotNil(t, auth.IsValid(), "Should require key file")
+}
+
+func parseTestJWT(t *testing.T, headerValue string) (map[string]interface{}, map[string]interface{}, string, []byte) {
+	require.True(t, strings.HasPrefix(headerValue, "Bearer "), "Should send token as Bearer")
+
+	parts := strings.Split(strings.TrimPrefix(headerValue, "Bearer "), ".")
+	require.Equal(t, 3, len(parts), "Should have header, claims and signature")
+
+	var header, claims map[string]interface{}
+	headerJSON, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+	require.Nil(t, json.Unmarshal(headerJSON, &header), "Should encode header as JSON")
+	require.Nil(t, json.Unmarshal(claimsJSON, &claims), "Should encode claims as JSON")
+
+	return header, claims, parts[0] + "." + parts[1], signature
+}
+
+func writeTestKey(t *testing.T, blockType string, keyBytes []byte) string {
+	dir, dirErr := ioutil.TempDir("", "jwt")
+	require.Nil(t, dirErr, "Should create temp dir")
+
+	keyFile := filepath.Join(dir, "key.pem")
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: keyBytes})
+	require.Nil(t, ioutil.WriteFile(keyFile, keyPEM, 0600), "Should write key file")
+	return keyFile
+}
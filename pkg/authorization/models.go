@@ -4,40 +4,252 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+
+	"github.com/visola/go-http-cli/pkg/credential"
+	"github.com/visola/variables/variables"
+)
+
+const (
+	// AWSSigV4AuthorizationType is the type for AWS Signature Version 4 request signing
+	AWSSigV4AuthorizationType = "aws-sigv4"
+
+	// BasicAuthorizationType is the type for HTTP Basic authorization
+	BasicAuthorizationType = "basic"
+
+	// BearerAuthorizationType is the type for a static Bearer token
+	BearerAuthorizationType = "bearer"
+
+	// DigestAuthorizationType is the type for HTTP Digest authorization
+	DigestAuthorizationType = "digest"
+
+	// HMACAuthorizationType is the type for generic HMAC request signing
+	HMACAuthorizationType = "hmac"
+
+	// JWTAuthorizationType is the type for JWTs minted and signed with a local private key
+	JWTAuthorizationType = "jwt"
+
+	// OAuth1AuthorizationType is the type for OAuth 1.0a request signing
+	OAuth1AuthorizationType = "oauth1"
+
+	// OAuth2AuthorizationCodeAuthorizationType is the type for OAuth2 authorization code grant with PKCE
+	OAuth2AuthorizationCodeAuthorizationType = "oauth2-authorization-code"
+
+	// OAuth2ClientCredentialsAuthorizationType is the type for OAuth2 client credentials grant
+	OAuth2ClientCredentialsAuthorizationType = "oauth2-client-credentials"
+
+	// OAuth2DeviceCodeAuthorizationType is the type for OAuth2 device authorization grant
+	OAuth2DeviceCodeAuthorizationType = "oauth2-device-code"
 )
 
 // Authorization represents an HTTP authorization
 type Authorization struct {
-	AuthorizationType string
-	Password          string
-	Token             string
-	Username          string
+	AccessKeyID            string
+	Algorithm              string
+	AuthorizationType      string
+	AuthorizationURL       string
+	CanonicalString        string
+	Claims                 map[string]string
+	ClientID               string
+	ClientSecret           string
+	ConsumerKey            string
+	ConsumerSecret         string
+	DeviceAuthorizationURL string
+	Encoding               string
+	Header                 string
+	HeaderValue            string
+	KeyFile                string
+	KeyID                  string
+	Password               string
+	PasswordCommand        credential.Command `json:"-"` // Never sent to the daemon, which loads it from the profile
+	PrivateKey             string
+	Realm                  string
+	RedirectURL            string
+	Region                 string
+	Scopes                 []string
+	Secret                 string
+	SecretAccessKey        string
+	Service                string
+	SessionToken           string
+	SignatureMethod        string
+	TimestampHeader        string
+	Token                  string
+	TokenCommand           credential.Command `json:"-"` // Never sent to the daemon, which loads it from the profile
+	TokenSecret            string
+	TokenURL               string
+	Username               string
+}
+
+// IsDynamic returns true if the header value for this authorization can only be calculated when
+// the request is executed
+func (auth Authorization) IsDynamic() bool {
+	if auth.PasswordCommand.IsSet() || auth.TokenCommand.IsSet() {
+		return true
+	}
+
+	authType := strings.ToLower(auth.AuthorizationType)
+	return authType == AWSSigV4AuthorizationType ||
+		authType == DigestAuthorizationType ||
+		authType == HMACAuthorizationType ||
+		authType == JWTAuthorizationType ||
+		authType == OAuth1AuthorizationType ||
+		authType == OAuth2ClientCredentialsAuthorizationType ||
+		auth.IsInteractive()
+}
+
+// IsInteractive returns true if this authorization requires the user to login
+func (auth Authorization) IsInteractive() bool {
+	authType := strings.ToLower(auth.AuthorizationType)
+	return authType == OAuth2AuthorizationCodeAuthorizationType || authType == OAuth2DeviceCodeAuthorizationType
 }
 
 // IsValid checks if this authorization is valid or not
 func (auth Authorization) IsValid() error {
 	authType := strings.ToLower(auth.AuthorizationType)
 
-	if authType == "basic" {
-		if auth.Username == "" || auth.Password == "" {
+	if authType == AWSSigV4AuthorizationType {
+		if auth.AccessKeyID == "" || auth.SecretAccessKey == "" || auth.Region == "" || auth.Service == "" {
+			return errors.New("Access key ID, secret access key, region and service must not be empty for AWS Signature V4 auth")
+		}
+
+		return nil
+	}
+
+	if authType == BasicAuthorizationType || authType == DigestAuthorizationType {
+		if auth.Username == "" || (auth.Password == "" && !auth.PasswordCommand.IsSet()) {
 			return fmt.Errorf("Username and password must not be empty but where '%s' and '%s' respectively", auth.Username, auth.Password)
 		}
 
 		return nil
 	}
 
-	if authType == "bearer" {
-		if auth.Token == "" {
+	if authType == BearerAuthorizationType {
+		if auth.Token == "" && !auth.TokenCommand.IsSet() {
 			return errors.New("Token must not be empty for Bearer auth")
 		}
 
 		return nil
 	}
 
+	if authType == HMACAuthorizationType {
+		return validateHMAC(auth)
+	}
+
+	if authType == JWTAuthorizationType {
+		return validateJWT(auth)
+	}
+
+	if authType == OAuth1AuthorizationType {
+		return validateOAuth1(auth)
+	}
+
+	if authType == OAuth2ClientCredentialsAuthorizationType {
+		if auth.TokenURL == "" || auth.ClientID == "" || auth.ClientSecret == "" {
+			return errors.New("Token URL, client ID and client secret must not be empty for OAuth2 client credentials auth")
+		}
+
+		return nil
+	}
+
+	if authType == OAuth2AuthorizationCodeAuthorizationType {
+		if auth.AuthorizationURL == "" || auth.TokenURL == "" || auth.ClientID == "" {
+			return errors.New("Authorization URL, token URL and client ID must not be empty for OAuth2 authorization code auth")
+		}
+
+		return nil
+	}
+
+	if authType == OAuth2DeviceCodeAuthorizationType {
+		if auth.DeviceAuthorizationURL == "" || auth.TokenURL == "" || auth.ClientID == "" {
+			return errors.New("Device authorization URL, token URL and client ID must not be empty for OAuth2 device code auth")
+		}
+
+		return nil
+	}
+
 	return fmt.Errorf("Unsupported auth type: %s", authType)
 }
 
+// Merge returns a new authorization with the values set in toMerge overriding the ones in this
+// authorization. If toMerge is of a different type, it replaces this authorization completely.
+func (auth Authorization) Merge(toMerge Authorization) Authorization {
+	if toMerge.AuthorizationType != "" && !strings.EqualFold(toMerge.AuthorizationType, auth.AuthorizationType) {
+		return toMerge
+	}
+
+	result := auth
+	resultValue := reflect.ValueOf(&result).Elem()
+	toMergeValue := reflect.ValueOf(toMerge)
+	for index := 0; index < toMergeValue.NumField(); index++ {
+		if field := toMergeValue.Field(index); !field.IsZero() {
+			resultValue.Field(index).Set(field)
+		}
+	}
+
+	return result
+}
+
+// ReplaceVariables returns a copy of this authorization with variables replaced in all its values.
+// Templates for HMAC signatures are not replaced because they have their own placeholders.
+func (auth Authorization) ReplaceVariables(values map[string]string) Authorization {
+	result := auth
+	resultValue := reflect.ValueOf(&result).Elem()
+	for index := 0; index < resultValue.NumField(); index++ {
+		name := resultValue.Type().Field(index).Name
+		if name == "CanonicalString" || name == "HeaderValue" {
+			continue
+		}
+
+		field := resultValue.Field(index)
+		switch field.Kind() {
+		case reflect.String:
+			field.SetString(variables.ReplaceVariables(field.String(), values))
+		case reflect.Map:
+			if field.IsNil() {
+				continue
+			}
+			replaced := make(map[string]string, field.Len())
+			for key, value := range field.Interface().(map[string]string) {
+				replaced[key] = variables.ReplaceVariables(value, values)
+			}
+			field.Set(reflect.ValueOf(replaced))
+		case reflect.Slice:
+			replaced := make([]string, field.Len())
+			for valueIndex := range replaced {
+				replaced[valueIndex] = variables.ReplaceVariables(field.Index(valueIndex).String(), values)
+			}
+			field.Set(reflect.ValueOf(replaced))
+		}
+	}
+
+	return result
+}
+
+// ResolveCommands returns a copy of this authorization with the password and token set to the
+// output of their commands, if configured
+func (auth Authorization) ResolveCommands() (Authorization, error) {
+	result := auth
+
+	if auth.PasswordCommand.IsSet() {
+		password, passwordErr := auth.PasswordCommand.Run()
+		if passwordErr != nil {
+			return auth, passwordErr
+		}
+		result.Password = password
+	}
+
+	if auth.TokenCommand.IsSet() {
+		token, tokenErr := auth.TokenCommand.Run()
+		if tokenErr != nil {
+			return auth, tokenErr
+		}
+		result.Token = token
+	}
+
+	return result, nil
+}
+
 // ToHeaderKey returns the key to be used when adding this authorization as a header.
 func (auth Authorization) ToHeaderKey() string {
 	return "Authorization"
@@ -53,12 +265,12 @@ func (auth Authorization) ToHeaderValue() (string, error) {
 
 	authType := strings.ToLower(auth.AuthorizationType)
 
-	if authType == "basic" {
+	if authType == BasicAuthorizationType {
 		toEncode := fmt.Sprintf("%s:%s", auth.Username, auth.Password)
 		return fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(toEncode))), nil
 	}
 
-	if authType == "bearer" {
+	if authType == BearerAuthorizationType {
 		return fmt.Sprintf("Bearer %s", auth.Token), nil
 	}
 
@@ -32,3 +32,13 @@ func TestBearerAuthorization(t *testing.T) {
 	assert.Nil(t, encodeError, "Should encode correctly using Basic auth")
 	assert.Equal(t, "Bearer "+token, encoded, "Should generate correct header value")
 }
+
+func TestMerge(t *testing.T) {
+	fromProfile := Authorization{AuthorizationType: BasicAuthorizationType, Username: "someone"}
+
+	merged := fromProfile.Merge(Authorization{Password: "secret"})
+	assert.Equal(t, Authorization{AuthorizationType: BasicAuthorizationType, Password: "secret", Username: "someone"}, merged, "Should merge values into the same authorization")
+
+	other := Authorization{AuthorizationType: BearerAuthorizationType, Token: "some-token"}
+	assert.Equal(t, other, fromProfile.Merge(other), "Should replace authorization of a different type")
+}
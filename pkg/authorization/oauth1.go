@@ -0,0 +1,235 @@
+package authorization
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/visola/go-http-cli/pkg/model"
+)
+
+const (
+	// OAuth1HMACSHA1SignatureMethod signs OAuth 1.0a requests using the consumer and token secrets
+	OAuth1HMACSHA1SignatureMethod = "HMAC-SHA1"
+
+	// OAuth1RSASHA1SignatureMethod signs OAuth 1.0a requests using an RSA private key
+	OAuth1RSASHA1SignatureMethod = "RSA-SHA1"
+
+	oauth1FormMimeType = "application/x-www-form-urlencoded"
+)
+
+// SignOAuth1 signs a request as defined in RFC 5849 and returns the Authorization header that
+// needs to be added to the request
+func SignOAuth1(auth Authorization, toSign RequestToSign) (map[string]string, error) {
+	return signOAuth1(auth, toSign, randomString(16))
+}
+
+func signOAuth1(auth Authorization, toSign RequestToSign, nonce string) (map[string]string, error) {
+	if validationErr := auth.IsValid(); validationErr != nil {
+		return nil, validationErr
+	}
+
+	signatureMethod := strings.ToUpper(coalesce(auth.SignatureMethod, OAuth1HMACSHA1SignatureMethod))
+
+	oauthParams := map[string]string{
+		"oauth_consumer_key":     auth.ConsumerKey,
+		"oauth_nonce":            nonce,
+		"oauth_signature_method": signatureMethod,
+		"oauth_timestamp":        strconv.FormatInt(toSign.Time.Unix(), 10),
+		"oauth_version":          "1.0",
+	}
+
+	if auth.Token != "" {
+		oauthParams["oauth_token"] = auth.Token
+	}
+
+	baseString, baseStringErr := oauth1BaseString(toSign, oauthParams)
+	if baseStringErr != nil {
+		return nil, baseStringErr
+	}
+
+	signature, signErr := calculateOAuth1Signature(auth, signatureMethod, baseString)
+	if signErr != nil {
+		return nil, signErr
+	}
+	oauthParams["oauth_signature"] = signature
+
+	names := make([]string, 0, len(oauthParams))
+	for name := range oauthParams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names)+1)
+	if auth.Realm != "" {
+		parts = append(parts, fmt.Sprintf(`realm="%s"`, oauth1Encode(auth.Realm)))
+	}
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, name, oauth1Encode(oauthParams[name])))
+	}
+
+	return map[string]string{
+		auth.ToHeaderKey(): "OAuth " + strings.Join(parts, ", "),
+	}, nil
+}
+
+// oauth1BaseString creates the signature base string from the method, the URL without query and
+// the parameters from the query, form encoded body and OAuth protocol
+func oauth1BaseString(toSign RequestToSign, oauthParams map[string]string) (string, error) {
+	params := make([]model.KeyValuePair, 0)
+	addParam := func(name string, value string) {
+		params = append(params, model.KeyValuePair{Name: oauth1Encode(name), Value: oauth1Encode(value)})
+	}
+	addParams := func(values url.Values) {
+		for name, valuesForName := range values {
+			for _, value := range valuesForName {
+				addParam(name, value)
+			}
+		}
+	}
+
+	query, queryErr := url.ParseQuery(toSign.URL.RawQuery)
+	if queryErr != nil {
+		return "", queryErr
+	}
+	addParams(query)
+
+	if isFormEncoded(toSign.Headers) {
+		form, formErr := url.ParseQuery(toSign.Body)
+		if formErr != nil {
+			return "", formErr
+		}
+		addParams(form)
+	}
+
+	for name, value := range oauthParams {
+		addParam(name, value)
+	}
+
+	// Parameters are sorted by encoded name and then by encoded value (RFC 5849, section 3.4.1.3.2).
+	// Sorting the joined pairs instead would put "a-b=" before "a=".
+	sort.Slice(params, func(i, j int) bool {
+		if params[i].Name != params[j].Name {
+			return params[i].Name < params[j].Name
+		}
+		return params[i].Value < params[j].Value
+	})
+
+	pairs := make([]string, len(params))
+	for i, param := range params {
+		pairs[i] = param.Name + "=" + param.Value
+	}
+
+	return strings.Join([]string{
+		oauth1Encode(strings.ToUpper(toSign.Method)),
+		oauth1Encode(oauth1BaseURL(toSign.URL)),
+		oauth1Encode(strings.Join(pairs, "&")),
+	}, "&"), nil
+}
+
+// oauth1BaseURL returns the URL without query and fragment, with lower case scheme and host and
+// without default ports
+func oauth1BaseURL(requestURL *url.URL) string {
+	scheme := strings.ToLower(requestURL.Scheme)
+	host := strings.ToLower(requestURL.Hostname())
+	if port := requestURL.Port(); port != "" && !(scheme == "http" && port == "80") && !(scheme == "https" && port == "443") {
+		host = host + ":" + port
+	}
+
+	path := requestURL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	return scheme + "://" + host + path
+}
+
+func calculateOAuth1Signature(auth Authorization, signatureMethod string, baseString string) (string, error) {
+	if signatureMethod == OAuth1RSASHA1SignatureMethod {
+		privateKey, keyErr := loadRSAPrivateKey(auth)
+		if keyErr != nil {
+			return "", keyErr
+		}
+
+		hashed := sha1.Sum([]byte(baseString))
+		signature, signErr := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA1, hashed[:])
+		if signErr != nil {
+			return "", signErr
+		}
+		return base64.StdEncoding.EncodeToString(signature), nil
+	}
+
+	key := oauth1Encode(auth.ConsumerSecret) + "&" + oauth1Encode(auth.TokenSecret)
+	mac := hmac.New(sha1.New, []byte(key))
+	mac.Write([]byte(baseString))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func isFormEncoded(headers map[string][]string) bool {
+	for name, values := range headers {
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Type") && len(values) > 0 {
+			return strings.HasPrefix(strings.ToLower(strings.TrimSpace(values[0])), oauth1FormMimeType)
+		}
+	}
+	return false
+}
+
+// oauth1Encode percent encodes everything except unreserved characters as required by RFC 5849
+func oauth1Encode(toEncode string) string {
+	return awsURIEncode(toEncode, true)
+}
+
+// loadRSAPrivateKey parses the PEM encoded RSA private key set in the authorization or, if none
+// is set, read from the key file
+func loadRSAPrivateKey(auth Authorization) (*rsa.PrivateKey, error) {
+	pemData := []byte(auth.PrivateKey)
+	if auth.PrivateKey == "" {
+		var readErr error
+		if pemData, readErr = ioutil.ReadFile(auth.KeyFile); readErr != nil {
+			return nil, fmt.Errorf("Error while reading OAuth1 key file: %s", readErr)
+		}
+	}
+
+	privateKey, parseErr := parsePrivateKey(pemData)
+	if parseErr != nil {
+		return nil, parseErr
+	}
+
+	rsaKey, isRSA := privateKey.(*rsa.PrivateKey)
+	if !isRSA {
+		return nil, errors.New("Private key for OAuth1 RSA-SHA1 must be an RSA key")
+	}
+
+	return rsaKey, nil
+}
+
+func validateOAuth1(auth Authorization) error {
+	if auth.ConsumerKey == "" {
+		return errors.New("Consumer key must not be empty for OAuth1 auth")
+	}
+
+	switch strings.ToUpper(coalesce(auth.SignatureMethod, OAuth1HMACSHA1SignatureMethod)) {
+	case OAuth1HMACSHA1SignatureMethod:
+		if auth.ConsumerSecret == "" {
+			return errors.New("Consumer secret must not be empty for OAuth1 HMAC-SHA1 auth")
+		}
+	case OAuth1RSASHA1SignatureMethod:
+		if auth.PrivateKey == "" && auth.KeyFile == "" {
+			return errors.New("Private key or key file must be set for OAuth1 RSA-SHA1 auth")
+		}
+	default:
+		return fmt.Errorf("Unsupported OAuth1 signature method '%s', must be one of: %s, %s", auth.SignatureMethod, OAuth1HMACSHA1SignatureMethod, OAuth1RSASHA1SignatureMethod)
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package authorization
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignOAuth1(t *testing.T) {
+	t.Run("Signs with HMAC-SHA1 including query and form body", testSignsOAuth1WithHMACSHA1)
+	t.Run("Signs with RSA-SHA1", testSignsOAuth1WithRSASHA1)
+	t.Run("Signs with RSA-SHA1 key file", testSignsOAuth1WithRSASHA1KeyFile)
+	t.Run("Creates base string from final URL", testCreatesOAuth1BaseString)
+	t.Run("Sorts parameters by name before value", testSortsOAuth1ParametersByName)
+	t.Run("Validates configuration", testValidatesOAuth1)
+}
+
+func testSignsOAuth1WithHMACSHA1(t *testing.T) {
+	auth := Authorization{
+		AuthorizationType: OAuth1AuthorizationType,
+		ConsumerKey:       "xvz1evFS4wEEPTGEFPHBog",
+		ConsumerSecret:    "kAcSOqF21Fu85e7zjz7ZN2U4ZRhfV3WpwPAoE3Z7kBw",
+		Token:             "370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb",
+		TokenSecret:       "LswwdoUaIvS8ltyTt5jkRh4J50vUPVVHtR2YPi5kE",
+	}
+
+	toSign := createOAuth1RequestToSign(
+		"https://api.twitter.com/1.1/statuses/update.json?include_entities=true",
+		"status=Hello%20Ladies%20%2b%20Gentlemen%2c%20a%20signed%20OAuth%20request%21",
+	)
+
+	headers, err := signOAuth1(auth, toSign, "kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg")
+
+	assert.Nil(t, err, "Should sign request")
+	assert.Equal(t, `OAuth oauth_consumer_key="xvz1evFS4wEEPTGEFPHBog", `+
+		`oauth_nonce="kYjzVBB8Y0ZFabxSWbWovY3uYSQ2pTgmZeNu2VS4cg", `+
+		`oauth_signature="hCtSmYh%2BiHYCEqBWrE7C7hYmtUk%3D", `+
+		`oauth_signature_method="HMAC-SHA1", `+
+		`oauth_timestamp="1318622958", `+
+		`oauth_token="370773112-GmHxMAgYyLbNEtIKZeRNFsMKPR9EyMZeS9weJAEb", `+
+		`oauth_version="1.0"`, headers["Authorization"], "Should set signature in authorization header")
+}
+
+func testSignsOAuth1WithRSASHA1(t *testing.T) {
+	privateKey, keyErr := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, keyErr, "Should generate key")
+
+	auth := Authorization{
+		AuthorizationType: OAuth1AuthorizationType,
+		ConsumerKey:       "my-consumer",
+		PrivateKey:        string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})),
+		Realm:             "Example",
+		SignatureMethod:   "rsa-sha1",
+	}
+
+	toSign := createOAuth1RequestToSign("http://localhost/orders?a=1", "")
+	headers, err := signOAuth1(auth, toSign, "some-nonce")
+	require.Nil(t, err, "Should sign request")
+
+	header := headers["Authorization"]
+	assert.True(t, strings.HasPrefix(header, `OAuth realm="Example", `), "Should start with realm")
+	assert.Contains(t, header, `oauth_signature_method="RSA-SHA1"`, "Should set signature method")
+	assert.NotContains(t, header, "oauth_token", "Should not send token if not configured")
+
+	signatureStart := strings.Index(header, `oauth_signature="`) + len(`oauth_signature="`)
+	encodedSignature, _ := url.QueryUnescape(header[signatureStart : signatureStart+strings.Index(header[signatureStart:], `"`)])
+	signature, _ := base64.StdEncoding.DecodeString(encodedSignature)
+
+	baseString, _ := oauth1BaseString(toSign, map[string]string{
+		"oauth_consumer_key":     "my-consumer",
+		"oauth_nonce":            "some-nonce",
+		"oauth_signature_method": "RSA-SHA1",
+		"oauth_timestamp":        "1318622958",
+		"oauth_version":          "1.0",
+	})
+	hashed := sha1.Sum([]byte(baseString))
+	assert.Nil(t, rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA1, hashed[:], signature), "Should sign base string with private key")
+}
+
+func testSignsOAuth1WithRSASHA1KeyFile(t *testing.T) {
+	privateKey, keyErr := rsa.GenerateKey(rand.Reader, 2048)
+	require.Nil(t, keyErr, "Should generate key")
+
+	auth := Authorization{
+		AuthorizationType: OAuth1AuthorizationType,
+		ConsumerKey:       "my-consumer",
+		KeyFile:           writeTestKey(t, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(privateKey)),
+		SignatureMethod:   "RSA-SHA1",
+	}
+
+	toSign := createOAuth1RequestToSign("http://localhost/orders", "")
+	headers, err := signOAuth1(auth, toSign, "some-nonce")
+	require.Nil(t, err, "Should sign request")
+
+	header := headers["Authorization"]
+	signatureStart := strings.Index(header, `oauth_signature="`) + len(`oauth_signature="`)
+	encodedSignature, _ := url.QueryUnescape(header[signatureStart : signatureStart+strings.Index(header[signatureStart:], `"`)])
+	signature, _ := base64.StdEncoding.DecodeString(encodedSignature)
+
+	baseString, _ := oauth1BaseString(toSign, map[string]string{
+		"oauth_consumer_key":     "my-consumer",
+		"oauth_nonce":            "some-nonce",
+		"oauth_signature_method": "RSA-SHA1",
+		"oauth_timestamp":        "1318622958",
+		"oauth_version":          "1.0",
+	})
+	hashed := sha1.Sum([]byte(baseString))
+	assert.Nil(t, rsa.VerifyPKCS1v15(&privateKey.PublicKey, crypto.SHA1, hashed[:], signature), "Should sign base string with key from file")
+
+	auth.KeyFile = filepath.Join(filepath.Dir(auth.KeyFile), "missing.pem")
+	_, err = signOAuth1(auth, toSign, "some-nonce")
+	assert.NotNil(t, err, "Should fail if key file can't be read")
+}
+
+func testCreatesOAuth1BaseString(t *testing.T) {
+	toSign := createOAuth1RequestToSign("HTTP://Example.COM:80/a%20b?b=2&a=3&a=1", "")
+	toSign.Headers = map[string][]string{"Content-Type": {"application/json"}}
+	toSign.Body = "c=ignored"
+
+	baseString, err := oauth1BaseString(toSign, map[string]string{"oauth_consumer_key": "key"})
+
+	assert.Nil(t, err, "Should create base string")
+	assert.Equal(t, "POST&http%3A%2F%2Fexample.com%2Fa%2520b&a%3D1%26a%3D3%26b%3D2%26oauth_consumer_key%3Dkey", baseString, "Should normalize URL and parameters, ignoring non form bodies")
+}
+
+func testSortsOAuth1ParametersByName(t *testing.T) {
+	toSign := createOAuth1RequestToSign("http://example.com/?a-b=1&a=2&a=1", "")
+
+	baseString, err := oauth1BaseString(toSign, map[string]string{})
+
+	assert.Nil(t, err, "Should create base string")
+	assert.Equal(t, "POST&http%3A%2F%2Fexample.com%2F&a%3D1%26a%3D2%26a-b%3D1", baseString, "Should sort by name and then by value")
+}
+
+func testValidatesOAuth1(t *testing.T) {
+	auth := Authorization{
+		AuthorizationType: OAuth1AuthorizationType,
+		ConsumerKey:       "my-consumer",
+		ConsumerSecret:    "my-secret",
+	}
+	assert.Nil(t, auth.IsValid(), "Should be valid with defaults")
+
+	auth.SignatureMethod = "PLAINTEXT"
+	assert.NotNil(t, auth.IsValid(), "Should not accept unsupported signature method")
+
+	auth.SignatureMethod = "RSA-SHA1"
+	assert.NotNil(t, auth.IsValid(), "Should require private key for RSA-SHA1")
+
+	auth.KeyFile = "key.pem"
+	assert.Nil(t, auth.IsValid(), "Should accept key file for RSA-SHA1")
+	auth.KeyFile = ""
+
+	auth.SignatureMethod = ""
+	auth.ConsumerKey = ""
+	assert.NotNil(t, auth.IsValid(), "Should require consumer key")
+}
+
+func createOAuth1RequestToSign(toParse string, body string) RequestToSign {
+	parsedURL, _ := url.Parse(toParse)
+	return RequestToSign{
+		Body:    body,
+		Headers: map[string][]string{"Content-Type": {"application/x-www-form-urlencoded"}},
+		Method:  "POST",
+		Time:    time.Unix(1318622958, 0),
+		URL:     parsedURL,
+	}
+}
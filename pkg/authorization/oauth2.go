@@ -0,0 +1,173 @@
+package authorization
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrLoginRequired is returned when an interactive authorization doesn't have valid tokens and the
+// user needs to login again
+var ErrLoginRequired = errors.New("Login required")
+
+// LoginRequiredError is returned when the user needs to login again. It carries the authorization as
+// resolved when executing the request, with variables, secrets and commands replaced, and the key
+// the tokens need to be stored with.
+type LoginRequiredError struct {
+	Auth     Authorization
+	CacheKey string
+}
+
+func (loginErr *LoginRequiredError) Error() string {
+	return ErrLoginRequired.Error()
+}
+
+func (loginErr *LoginRequiredError) Unwrap() error {
+	return ErrLoginRequired
+}
+
+// TokenError is an error returned by an OAuth2 token endpoint
+type TokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+	Status      string `json:"-"`
+	TokenURL    string `json:"-"`
+}
+
+func (tokenErr *TokenError) Error() string {
+	message := fmt.Sprintf("Token endpoint %s responded with %s: %s", tokenErr.TokenURL, tokenErr.Status, tokenErr.Code)
+	if tokenErr.Description != "" {
+		message += " - " + tokenErr.Description
+	}
+	return message
+}
+
+// TokenResponse is the response returned by an OAuth2 token endpoint
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+}
+
+// ToTokens converts the token response to tokens that can be stored
+func (tokenResponse TokenResponse) ToTokens() Tokens {
+	tokens := Tokens{
+		AccessToken:  tokenResponse.AccessToken,
+		RefreshToken: tokenResponse.RefreshToken,
+	}
+
+	if tokenResponse.ExpiresIn > 0 {
+		expiresIn := time.Duration(tokenResponse.ExpiresIn) * time.Second
+		tokens.ExpiresAt = now().Add(expiresIn - tokenExpirationMargin)
+	}
+
+	return tokens
+}
+
+// Tokens are the tokens obtained through an OAuth2 flow
+type Tokens struct {
+	AccessToken  string
+	ExpiresAt    time.Time
+	RefreshToken string
+}
+
+// IsExpired returns true if the access token expired. Tokens without expiration never expire.
+func (tokens Tokens) IsExpired() bool {
+	return !tokens.ExpiresAt.IsZero() && !now().Before(tokens.ExpiresAt)
+}
+
+// RefreshAccessToken uses a refresh token to get a new access token, requesting it with the client
+func RefreshAccessToken(client *http.Client, auth Authorization, refreshToken string) (*Tokens, error) {
+	form := url.Values{}
+	form.Set("grant_type", "refresh_token")
+	form.Set("refresh_token", refreshToken)
+
+	tokenResponse, requestErr := requestToken(client, auth.TokenURL, form, auth.ClientID, auth.ClientSecret)
+	if requestErr != nil {
+		return nil, requestErr
+	}
+
+	tokens := tokenResponse.ToTokens()
+
+	// Servers are not required to rotate refresh tokens
+	if tokens.RefreshToken == "" {
+		tokens.RefreshToken = refreshToken
+	}
+
+	return &tokens, nil
+}
+
+// TokenCacheKey generates the key used to cache tokens for an authorization in a set of profiles.
+// Tokens for different scopes or client secrets are cached separately, the secret is only hashed.
+func TokenCacheKey(profileNames []string, auth Authorization) string {
+	scopes := append([]string{}, auth.Scopes...)
+	sort.Strings(scopes)
+
+	secretHash := sha256.Sum256([]byte(auth.ClientSecret))
+	return strings.Join([]string{
+		strings.Join(profileNames, ","),
+		auth.TokenURL,
+		auth.ClientID,
+		strings.Join(scopes, " "),
+		hex.EncodeToString(secretHash[:]),
+	}, "|")
+}
+
+func requestToken(client *http.Client, tokenURL string, form url.Values, clientID string, clientSecret string) (*TokenResponse, error) {
+	// Public clients identify themselves in the body
+	if clientSecret == "" {
+		form.Set("client_id", clientID)
+	}
+
+	req, reqErr := http.NewRequest(http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if reqErr != nil {
+		return nil, reqErr
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, respErr := client.Do(req)
+	if respErr != nil {
+		return nil, fmt.Errorf("Error while requesting token from %s: %s", tokenURL, respErr)
+	}
+	defer resp.Body.Close()
+
+	body, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		tokenErr := &TokenError{}
+		if json.Unmarshal(body, tokenErr) != nil || tokenErr.Code == "" {
+			tokenErr.Code = strings.TrimSpace(string(body))
+		}
+		tokenErr.Status = resp.Status
+		tokenErr.TokenURL = tokenURL
+		return nil, tokenErr
+	}
+
+	var tokenResponse TokenResponse
+	if unmarshalErr := json.Unmarshal(body, &tokenResponse); unmarshalErr != nil {
+		return nil, fmt.Errorf("Error while parsing token response from %s: %s", tokenURL, unmarshalErr)
+	}
+
+	if tokenResponse.AccessToken == "" {
+		return nil, fmt.Errorf("Token endpoint %s did not return an access token", tokenURL)
+	}
+
+	return &tokenResponse, nil
+}
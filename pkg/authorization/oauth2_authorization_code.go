@@ -0,0 +1,186 @@
+package authorization
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultCallbackPath = "/callback"
+	loginTimeout        = 5 * time.Minute
+)
+
+type authorizationCodeResult struct {
+	code string
+	err  error
+}
+
+// LoginWithAuthorizationCode executes the OAuth2 authorization code flow with PKCE. It opens a
+// loopback listener to receive the redirect from the authorization server and calls
+// openAuthorizationURL with the URL the user needs to visit to authorize. Tokens are requested using
+// the client.
+func LoginWithAuthorizationCode(client *http.Client, auth Authorization, openAuthorizationURL func(string)) (*Tokens, error) {
+	if validationErr := auth.IsValid(); validationErr != nil {
+		return nil, validationErr
+	}
+
+	listenAddress, callbackPath, redirectErr := parseRedirectURL(auth.RedirectURL)
+	if redirectErr != nil {
+		return nil, redirectErr
+	}
+
+	listener, listenErr := net.Listen("tcp", listenAddress)
+	if listenErr != nil {
+		return nil, fmt.Errorf("Error while starting loopback listener for OAuth2 redirect: %s", listenErr)
+	}
+	defer listener.Close()
+
+	// Configured redirect URLs must be sent exactly as registered in the authorization server
+	redirectURL := auth.RedirectURL
+	if redirectURL == "" {
+		redirectURL = fmt.Sprintf("http://%s%s", listener.Addr().String(), callbackPath)
+	}
+
+	codeVerifier := randomString(32)
+	state := randomString(16)
+
+	results := make(chan authorizationCodeResult, 1)
+	server := &http.Server{Handler: createCallbackHandler(callbackPath, state, results)}
+	go server.Serve(listener)
+	defer server.Shutdown(context.Background())
+
+	openAuthorizationURL(buildAuthorizationURL(auth, redirectURL, state, codeVerifier))
+
+	var result authorizationCodeResult
+	select {
+	case result = <-results:
+	case <-time.After(loginTimeout):
+		return nil, errors.New("Timed out waiting for authorization")
+	}
+
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", result.code)
+	form.Set("redirect_uri", redirectURL)
+	form.Set("code_verifier", codeVerifier)
+
+	tokenResponse, tokenErr := requestToken(client, auth.TokenURL, form, auth.ClientID, auth.ClientSecret)
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
+
+	tokens := tokenResponse.ToTokens()
+	return &tokens, nil
+}
+
+func buildAuthorizationURL(auth Authorization, redirectURL string, state string, codeVerifier string) string {
+	challenge := sha256.Sum256([]byte(codeVerifier))
+
+	query := url.Values{}
+	query.Set("client_id", auth.ClientID)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	query.Set("redirect_uri", redirectURL)
+	query.Set("response_type", "code")
+	query.Set("state", state)
+	if len(auth.Scopes) > 0 {
+		query.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	separator := "?"
+	if strings.Contains(auth.AuthorizationURL, "?") {
+		separator = "&"
+	}
+
+	return auth.AuthorizationURL + separator + query.Encode()
+}
+
+func createCallbackHandler(callbackPath string, state string, results chan authorizationCodeResult) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != callbackPath {
+			http.NotFound(w, r)
+			return
+		}
+
+		query := r.URL.Query()
+		var result authorizationCodeResult
+		if errorCode := query.Get("error"); errorCode != "" {
+			result.err = fmt.Errorf("Authorization failed: %s %s", errorCode, query.Get("error_description"))
+		} else if query.Get("state") != state {
+			result.err = errors.New("Authorization failed: state returned by the authorization server doesn't match")
+		} else if query.Get("code") == "" {
+			result.err = errors.New("Authorization failed: no code returned by the authorization server")
+		} else {
+			result.code = query.Get("code")
+		}
+
+		if result.err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(w, result.err.Error())
+		} else {
+			fmt.Fprintln(w, "Authorization complete, you can close this window.")
+		}
+
+		select {
+		case results <- result:
+		default:
+			// Already received a result
+		}
+	})
+}
+
+// parseRedirectURL returns the address to listen to and the callback path. If no redirect URL is
+// configured, a random port in the loopback interface is used. Redirect URLs to other interfaces
+// are rejected, anyone that can reach them could receive the authorization code.
+func parseRedirectURL(redirectURL string) (string, string, error) {
+	if redirectURL == "" {
+		return "127.0.0.1:0", defaultCallbackPath, nil
+	}
+
+	parsedURL, parseErr := url.Parse(redirectURL)
+	if parseErr != nil {
+		return "", "", parseErr
+	}
+
+	host := parsedURL.Hostname()
+	if strings.EqualFold(host, "localhost") {
+		host = "127.0.0.1"
+	}
+
+	if ip := net.ParseIP(host); parsedURL.Scheme != "http" || ip == nil || !ip.IsLoopback() {
+		return "", "", fmt.Errorf("Redirect URL must be a loopback HTTP URL: %s", redirectURL)
+	}
+
+	callbackPath := parsedURL.Path
+	if callbackPath == "" {
+		callbackPath = "/"
+	}
+
+	port := parsedURL.Port()
+	if port == "" {
+		port = "80"
+	}
+
+	return net.JoinHostPort(host, port), callbackPath, nil
+}
+
+func randomString(size int) string {
+	data := make([]byte, size)
+	if _, err := rand.Read(data); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,105 @@
+package authorization
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizationCode(t *testing.T) {
+	t.Run("Logs in using PKCE", testLogsInWithAuthorizationCode)
+	t.Run("Fails if state doesn't match", testFailsIfStateDoesNotMatch)
+	t.Run("Only redirects to loopback", testOnlyRedirectsToLoopback)
+}
+
+func testLogsInWithAuthorizationCode(t *testing.T) {
+	var codeChallenge string
+	var tokenForm url.Values
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/authorize" {
+			query := r.URL.Query()
+			codeChallenge = query.Get("code_challenge")
+			assert.Equal(t, "S256", query.Get("code_challenge_method"), "Should use S256 challenge")
+			assert.Equal(t, "code", query.Get("response_type"), "Should request code")
+			assert.Equal(t, "my-client", query.Get("client_id"), "Should send client ID")
+			http.Redirect(w, r, query.Get("redirect_uri")+"?code=the-code&state="+query.Get("state"), http.StatusFound)
+			return
+		}
+
+		r.ParseForm()
+		tokenForm = r.PostForm
+		fmt.Fprint(w, `{"access_token":"user-token","refresh_token":"refresh-me","expires_in":3600}`)
+	}))
+	defer server.Close()
+
+	auth := Authorization{
+		AuthorizationType: OAuth2AuthorizationCodeAuthorizationType,
+		AuthorizationURL:  server.URL + "/authorize",
+		ClientID:          "my-client",
+		TokenURL:          server.URL + "/token",
+	}
+
+	tokens, err := LoginWithAuthorizationCode(http.DefaultClient, auth, func(authorizationURL string) {
+		// Simulates the browser
+		go http.Get(authorizationURL)
+	})
+
+	assert.Nil(t, err, "Should login")
+	if err != nil {
+		return
+	}
+
+	assert.Equal(t, "user-token", tokens.AccessToken, "Should return access token")
+	assert.Equal(t, "refresh-me", tokens.RefreshToken, "Should return refresh token")
+	assert.False(t, tokens.IsExpired(), "Should not be expired")
+
+	assert.Equal(t, "authorization_code", tokenForm.Get("grant_type"), "Should exchange code")
+	assert.Equal(t, "the-code", tokenForm.Get("code"), "Should send code received in the redirect")
+	assert.Equal(t, "my-client", tokenForm.Get("client_id"), "Public clients should send client ID")
+
+	verifierHash := sha256.Sum256([]byte(tokenForm.Get("code_verifier")))
+	assert.Equal(t, codeChallenge, base64.RawURLEncoding.EncodeToString(verifierHash[:]), "Code verifier should match challenge")
+}
+
+func testFailsIfStateDoesNotMatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, r.URL.Query().Get("redirect_uri")+"?code=the-code&state=wrong", http.StatusFound)
+	}))
+	defer server.Close()
+
+	auth := Authorization{
+		AuthorizationType: OAuth2AuthorizationCodeAuthorizationType,
+		AuthorizationURL:  server.URL + "/authorize",
+		ClientID:          "my-client",
+		TokenURL:          server.URL + "/token",
+	}
+
+	_, err := LoginWithAuthorizationCode(http.DefaultClient, auth, func(authorizationURL string) {
+		go http.Get(authorizationURL)
+	})
+
+	assert.NotNil(t, err, "Should fail")
+}
+
+func testOnlyRedirectsToLoopback(t *testing.T) {
+	listenAddress, callbackPath, err := parseRedirectURL("http://localhost:8085/callback")
+	assert.Nil(t, err, "Should accept localhost")
+	assert.Equal(t, "127.0.0.1:8085", listenAddress, "Should listen in the loopback interface")
+	assert.Equal(t, "/callback", callbackPath, "Should return callback path")
+
+	listenAddress, _, err = parseRedirectURL("http://[::1]:8085/")
+	assert.Nil(t, err, "Should accept IPv6 loopback")
+	assert.Equal(t, "[::1]:8085", listenAddress, "Should listen in the IPv6 loopback interface")
+
+	for _, redirectURL := range []string{"http://0.0.0.0:80/", "http://example.com/callback", "https://127.0.0.1:8085/"} {
+		_, _, err = parseRedirectURL(redirectURL)
+		assert.NotNil(t, err, "Should reject "+redirectURL)
+	}
+}
@@ -0,0 +1,63 @@
+package authorization
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tokens are renewed a little before they expire to account for the time it takes for the
+// request to reach the server
+const tokenExpirationMargin = 10 * time.Second
+
+var (
+	now        = time.Now
+	tokenCache = make(map[string]Tokens)
+	tokenMutex = &sync.Mutex{}
+)
+
+// GetClientCredentialsToken returns an access token for an OAuth2 client credentials authorization,
+// requesting it with the client. Tokens are cached using the cache key until they expire.
+func GetClientCredentialsToken(client *http.Client, cacheKey string, auth Authorization) (string, error) {
+	if validationErr := auth.IsValid(); validationErr != nil {
+		return "", validationErr
+	}
+
+	if cached, exists := getCachedToken(cacheKey); exists && !cached.IsExpired() {
+		return cached.AccessToken, nil
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	// The lock is not held while requesting, so a slow token endpoint doesn't block other requests
+	tokenResponse, requestErr := requestToken(client, auth.TokenURL, form, auth.ClientID, auth.ClientSecret)
+	if requestErr != nil {
+		return "", requestErr
+	}
+
+	tokenMutex.Lock()
+	defer tokenMutex.Unlock()
+
+	// Tokens without expiration are not cached
+	if tokenResponse.ExpiresIn > 0 {
+		tokenCache[cacheKey] = tokenResponse.ToTokens()
+	} else {
+		delete(tokenCache, cacheKey)
+	}
+
+	return tokenResponse.AccessToken, nil
+}
+
+func getCachedToken(cacheKey string) (Tokens, bool) {
+	tokenMutex.Lock()
+	defer tokenMutex.Unlock()
+
+	cached, exists := tokenCache[cacheKey]
+	return cached, exists
+}
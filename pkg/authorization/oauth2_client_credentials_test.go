@@ -0,0 +1,106 @@
+package authorization
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCredentialsToken(t *testing.T) {
+	t.Run("Fetches token from token URL", testFetchesClientCredentialsToken)
+	t.Run("Caches token until it expires", testCachesClientCredentialsToken)
+	t.Run("Fails when token endpoint fails", testFailsWhenTokenEndpointFails)
+	t.Run("Caches tokens by scopes and client secret", testCachesTokensByScopesAndSecret)
+}
+
+func testFetchesClientCredentialsToken(t *testing.T) {
+	var receivedRequest *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		receivedRequest = r
+		fmt.Fprint(w, `{"access_token":"some-token","expires_in":3600,"token_type":"bearer"}`)
+	}))
+	defer server.Close()
+
+	token, err := GetClientCredentialsToken(http.DefaultClient, "fetch", createClientCredentialsAuth(server.URL))
+
+	assert.Nil(t, err, "Should fetch token")
+	assert.Equal(t, "some-token", token, "Should return the access token")
+
+	username, password, hasBasicAuth := receivedRequest.BasicAuth()
+	assert.True(t, hasBasicAuth, "Should authenticate the client using basic auth")
+	assert.Equal(t, "my-client", username, "Should send client ID")
+	assert.Equal(t, "my-secret", password, "Should send client secret")
+	assert.Equal(t, "client_credentials", receivedRequest.PostForm.Get("grant_type"), "Should send grant type")
+	assert.Equal(t, "read write", receivedRequest.PostForm.Get("scope"), "Should send scopes separated by space")
+}
+
+func testCachesClientCredentialsToken(t *testing.T) {
+	requestCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestCount++
+		fmt.Fprintf(w, `{"access_token":"token-%d","expires_in":60}`, requestCount)
+	}))
+	defer server.Close()
+
+	auth := createClientCredentialsAuth(server.URL)
+
+	first, _ := GetClientCredentialsToken(http.DefaultClient, "cache", auth)
+	second, _ := GetClientCredentialsToken(http.DefaultClient, "cache", auth)
+	assert.Equal(t, "token-1", first, "Should fetch the first token")
+	assert.Equal(t, "token-1", second, "Should reuse cached token")
+	assert.Equal(t, 1, requestCount, "Should only request token once")
+
+	other, _ := GetClientCredentialsToken(http.DefaultClient, "other-profile", auth)
+	assert.Equal(t, "token-2", other, "Should cache tokens per cache key")
+
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	expired, _ := GetClientCredentialsToken(http.DefaultClient, "cache", auth)
+	assert.Equal(t, "token-3", expired, "Should fetch new token after the cached one expired")
+}
+
+func testFailsWhenTokenEndpointFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":"invalid_client"}`)
+	}))
+	defer server.Close()
+
+	_, err := GetClientCredentialsToken(http.DefaultClient, "fail", createClientCredentialsAuth(server.URL))
+	assert.NotNil(t, err, "Should return an error")
+}
+
+func createClientCredentialsAuth(tokenURL string) Authorization {
+	return Authorization{
+		AuthorizationType: OAuth2ClientCredentialsAuthorizationType,
+		ClientID:          "my-client",
+		ClientSecret:      "my-secret",
+		Scopes:            []string{"read", "write"},
+		TokenURL:          tokenURL,
+	}
+}
+
+func testCachesTokensByScopesAndSecret(t *testing.T) {
+	profiles := []string{"api"}
+	auth := createClientCredentialsAuth("http://localhost/token")
+
+	reordered := auth
+	reordered.Scopes = []string{"write", "read"}
+	assert.Equal(t, TokenCacheKey(profiles, auth), TokenCacheKey(profiles, reordered), "Should not depend on the order of the scopes")
+	assert.Equal(t, []string{"write", "read"}, reordered.Scopes, "Should not change the order of the scopes")
+
+	narrower := auth
+	narrower.Scopes = []string{"read"}
+	assert.NotEqual(t, TokenCacheKey(profiles, auth), TokenCacheKey(profiles, narrower), "Should cache tokens for other scopes separately")
+
+	otherSecret := auth
+	otherSecret.ClientSecret = "other-secret"
+	assert.NotEqual(t, TokenCacheKey(profiles, auth), TokenCacheKey(profiles, otherSecret), "Should cache tokens for other secrets separately")
+	assert.NotContains(t, TokenCacheKey(profiles, auth), auth.ClientSecret, "Should not keep the secret in the key")
+}
@@ -0,0 +1,124 @@
+package authorization
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	defaultDevicePollInterval = 5 * time.Second
+	deviceCodeGrantType       = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// Used to wait between polls, can be replaced in tests
+var sleep = time.Sleep
+
+// DeviceCodeResponse is the response returned by an OAuth2 device authorization endpoint
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+}
+
+// LoginWithDeviceCode executes the OAuth2 device authorization flow. It calls showDeviceCode with
+// the code and the URL the user needs to visit to authorize and then polls the token endpoint until
+// the user finishes authorizing. Codes and tokens are requested using the client.
+func LoginWithDeviceCode(client *http.Client, auth Authorization, showDeviceCode func(DeviceCodeResponse)) (*Tokens, error) {
+	if validationErr := auth.IsValid(); validationErr != nil {
+		return nil, validationErr
+	}
+
+	deviceCode, deviceCodeErr := requestDeviceCode(client, auth)
+	if deviceCodeErr != nil {
+		return nil, deviceCodeErr
+	}
+
+	showDeviceCode(*deviceCode)
+
+	interval := defaultDevicePollInterval
+	if deviceCode.Interval > 0 {
+		interval = time.Duration(deviceCode.Interval) * time.Second
+	}
+
+	expiresAt := now().Add(loginTimeout)
+	if deviceCode.ExpiresIn > 0 {
+		expiresAt = now().Add(time.Duration(deviceCode.ExpiresIn) * time.Second)
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", deviceCodeGrantType)
+	form.Set("device_code", deviceCode.DeviceCode)
+
+	for now().Before(expiresAt) {
+		sleep(interval)
+
+		tokenResponse, tokenErr := requestToken(client, auth.TokenURL, form, auth.ClientID, auth.ClientSecret)
+		if tokenErr == nil {
+			tokens := tokenResponse.ToTokens()
+			return &tokens, nil
+		}
+
+		var oauthErr *TokenError
+		if !errors.As(tokenErr, &oauthErr) {
+			return nil, tokenErr
+		}
+
+		switch oauthErr.Code {
+		case "authorization_pending":
+			continue
+		case "slow_down":
+			interval += 5 * time.Second
+		default:
+			return nil, tokenErr
+		}
+	}
+
+	return nil, errors.New("Device code expired before authorization was completed")
+}
+
+func requestDeviceCode(client *http.Client, auth Authorization) (*DeviceCodeResponse, error) {
+	form := url.Values{}
+	form.Set("client_id", auth.ClientID)
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+
+	req, reqErr := http.NewRequest(http.MethodPost, auth.DeviceAuthorizationURL, strings.NewReader(form.Encode()))
+	if reqErr != nil {
+		return nil, reqErr
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, respErr := client.Do(req)
+	if respErr != nil {
+		return nil, fmt.Errorf("Error while requesting device code from %s: %s", auth.DeviceAuthorizationURL, respErr)
+	}
+	defer resp.Body.Close()
+
+	body, readErr := ioutil.ReadAll(resp.Body)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Device authorization endpoint %s responded with %s: %s", auth.DeviceAuthorizationURL, resp.Status, string(body))
+	}
+
+	var deviceCode DeviceCodeResponse
+	if unmarshalErr := json.Unmarshal(body, &deviceCode); unmarshalErr != nil {
+		return nil, fmt.Errorf("Error while parsing device code response from %s: %s", auth.DeviceAuthorizationURL, unmarshalErr)
+	}
+
+	return &deviceCode, nil
+}
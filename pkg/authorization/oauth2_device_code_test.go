@@ -0,0 +1,84 @@
+package authorization
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeviceCode(t *testing.T) {
+	t.Run("Polls until user authorizes", testPollsUntilUserAuthorizes)
+	t.Run("Fails when user denies access", testFailsWhenUserDeniesAccess)
+}
+
+func testPollsUntilUserAuthorizes(t *testing.T) {
+	defer func() { sleep = time.Sleep }()
+	sleep = func(time.Duration) {}
+
+	pollCount := 0
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.URL.Path == "/device" {
+			fmt.Fprint(w, `{"device_code":"device-1","user_code":"ABCD-EFGH","verification_uri":"https://example.com/device","interval":1,"expires_in":600}`)
+			return
+		}
+
+		assert.Equal(t, deviceCodeGrantType, r.PostForm.Get("grant_type"), "Should use device code grant")
+		assert.Equal(t, "device-1", r.PostForm.Get("device_code"), "Should send device code")
+
+		pollCount++
+		if pollCount < 3 {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"authorization_pending"}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"access_token":"device-token","refresh_token":"refresh-me","expires_in":3600}`)
+	}))
+	defer server.Close()
+
+	var shownCode DeviceCodeResponse
+	// Only the server's client trusts its certificate
+	tokens, err := LoginWithDeviceCode(server.Client(), createDeviceCodeAuth(server.URL), func(deviceCode DeviceCodeResponse) {
+		shownCode = deviceCode
+	})
+
+	assert.Nil(t, err, "Should login")
+	assert.Equal(t, "ABCD-EFGH", shownCode.UserCode, "Should show user code")
+	assert.Equal(t, 3, pollCount, "Should poll until authorized")
+	if tokens != nil {
+		assert.Equal(t, "device-token", tokens.AccessToken, "Should return access token")
+	}
+}
+
+func testFailsWhenUserDeniesAccess(t *testing.T) {
+	defer func() { sleep = time.Sleep }()
+	sleep = func(time.Duration) {}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/device" {
+			fmt.Fprint(w, `{"device_code":"device-1","user_code":"ABCD-EFGH","verification_uri":"https://example.com/device"}`)
+			return
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"access_denied"}`)
+	}))
+	defer server.Close()
+
+	_, err := LoginWithDeviceCode(http.DefaultClient, createDeviceCodeAuth(server.URL), func(DeviceCodeResponse) {})
+	assert.NotNil(t, err, "Should fail")
+}
+
+func createDeviceCodeAuth(serverURL string) Authorization {
+	return Authorization{
+		AuthorizationType:      OAuth2DeviceCodeAuthorizationType,
+		ClientID:               "my-client",
+		DeviceAuthorizationURL: serverURL + "/device",
+		TokenURL:               serverURL + "/token",
+	}
+}
@@ -0,0 +1,17 @@
+package authorization
+
+import (
+	"net/url"
+	"time"
+)
+
+// RequestToSign holds the parts of a request that are used to calculate signatures. It should only
+// be created after all variables were replaced, right before the request is sent.
+type RequestToSign struct {
+	Body         string
+	Headers      map[string][]string
+	Method       string
+	StreamedBody bool // The body is streamed from a file, so it can't be part of the signature
+	Time         time.Time
+	URL          *url.URL
+}
@@ -1,25 +1,79 @@
 package base
 
+import (
+	"github.com/visola/go-http-cli/pkg/authorization"
+	"github.com/visola/go-http-cli/pkg/formdata"
+	"github.com/visola/go-http-cli/pkg/proxy"
+	"github.com/visola/go-http-cli/pkg/retry"
+	"github.com/visola/go-http-cli/pkg/timeout"
+	"github.com/visola/go-http-cli/pkg/tlsconfig"
+)
+
 // WithBody is something that has a configuration to allow insecure HTTP connections
 type WithAllowInsecure interface {
 	GetAllowInsecure() bool
 }
 
+// WithAuth is something that has an authorization
+type WithAuth interface {
+	GetAuth() (authorization.Authorization, error)
+}
+
 // WithBody is something that has a body
 type WithBody interface {
 	GetBody() (string, error)
 }
 
+// WithBodyFile is something that has a file to be streamed as the body
+type WithBodyFile interface {
+	GetBodyFile() (string, error)
+}
+
 // WithHeaders is something that has headers
 type WithHeaders interface {
 	GetHeaders() map[string][]string
 }
 
+// WithHTTPVersion is something that has a version of the protocol to use
+type WithHTTPVersion interface {
+	GetHTTPVersion() string
+}
+
 // WithMethod is something that has an HTTP method
 type WithMethod interface {
 	GetMethod() string
 }
 
+// WithMultipart is something that has parts of a multipart form body
+type WithMultipart interface {
+	GetMultipart() ([]formdata.Part, error)
+}
+
+// WithProxy is something that has a proxy configuration
+type WithProxy interface {
+	GetProxy() proxy.Options
+}
+
+// WithRetry is something that has a retry policy
+type WithRetry interface {
+	GetRetry() retry.Options
+}
+
+// WithTimeouts is something that has timeouts for the request
+type WithTimeouts interface {
+	GetTimeouts() timeout.Options
+}
+
+// WithTLS is something that has options to configure TLS connections
+type WithTLS interface {
+	GetTLS() (tlsconfig.Options, error)
+}
+
+// WithUnixSocket is something that has a Unix socket to send requests to
+type WithUnixSocket interface {
+	GetUnixSocket() (string, error)
+}
+
 // WithValues is something that has values
 type WithValues interface {
 	GetValues() map[string][]string
@@ -5,24 +5,52 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	flag "github.com/spf13/pflag"
+	"github.com/visola/go-http-cli/pkg/formdata"
+	"github.com/visola/go-http-cli/pkg/protocol"
+	"github.com/visola/go-http-cli/pkg/proxy"
+	"github.com/visola/go-http-cli/pkg/retry"
+	"github.com/visola/go-http-cli/pkg/timeout"
+	"github.com/visola/go-http-cli/pkg/upload"
 )
 
 // CommandLineOptions stores information that was requested by the user from the CLI.
 type CommandLineOptions struct {
 	AllowInsecure    bool
 	Body             string
+	CACert           string
+	Cert             string
+	ConnectTimeout   time.Duration
 	Headers          map[string][]string
+	HTTPVersion      string
 	FollowLocation   bool
 	FileToUpload     string
+	Form             []formdata.Part
+	Key              string
 	MaxAddedRequests int
 	MaxRedirect      int
+	MaxTime          time.Duration
 	Method           string
+	Netrc            bool
+	NetrcFile        string
+	NetrcOptional    bool
 	OutputFile       string
+	PinnedPublicKeys []string
 	PostProcessFile  string
 	Profiles         []string
+	Proxy            proxy.Options
+	RemoteName       bool
 	RequestName      string
+	RequestTimeout   time.Duration
+	ResponseTimeout  time.Duration
+	Retry            retry.Options
+	TLSMaxVersion    string
+	TLSMinVersion    string
+	Timings          bool
+	TLSTimeout       time.Duration
+	UnixSocket       string
 	URL              string
 	Values           map[string][]string
 	Variables        map[string]string
@@ -45,23 +73,57 @@ func (i *keyValuePair) Type() string {
 
 // ParseCommandLineOptions parses the arguments received on the command line and generate a basic configuration.
 func ParseCommandLineOptions(args []string) (*CommandLineOptions, error) {
-	var body, fileToUpload, method, outputFile, postProcessFile string
-	var configPaths, headers, variables keyValuePair
-	var allowInsecure, followLocation bool
+	var body, caCert, cert, fileToUpload, key, method, netrcFile, outputFile, postProcessFile, tlsMaxVersion, tlsMinVersion, unixSocket string
+	var noProxy, proxyURL, proxyUser string
+	var connectTimeout, maxTime, requestTimeout, responseTimeout, retryDelay, retryMaxDelay, retryOn, tlsTimeout string
+	var configPaths, formFields, headers, pinnedPublicKeys, variables keyValuePair
+	var allowInsecure, followLocation, netrc, netrcOptional, remoteName, retryNonIdempotent, timings bool
+	var http1, http2, http2PriorKnowledge, http3 bool
+	var retryAttempts int
 
 	commandLine := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
 
+	commandLine.StringVarP(&caCert, "cacert", "", "", "CA certificates file in PEM format to verify the server with")
+	commandLine.StringVarP(&cert, "cert", "E", "", "Client certificate file in PEM format, can also contain the private key")
 	commandLine.VarP(&configPaths, "config", "c", "Path to configuration files to be used")
-	commandLine.StringVarP(&body, "data", "d", "", "Data to be sent as body")
+	commandLine.StringVarP(&connectTimeout, "connect-timeout", "", "", "Maximum time to establish the connection, in seconds (e.g.: 2.5) or as a duration (e.g.: 500ms)")
+	commandLine.StringVarP(&body, "data", "d", "", "Data to be sent as body, @file to stream it from a file or @- from the standard input")
+	commandLine.VarP(&formFields, "form", "F", "Multipart form field as name=value or name=@file;type=<content type>;filename=<name>, @- for the standard input")
 	commandLine.VarP(&headers, "header", "H", "Headers to include with your request")
+	commandLine.BoolVarP(&http1, "http1.1", "", false, "Use HTTP/1.1")
+	commandLine.BoolVarP(&http2, "http2", "", false, "Use HTTP/2, failing if the server doesn't support it")
+	commandLine.BoolVarP(&http2PriorKnowledge, "http2-prior-knowledge", "", false, "Use HTTP/2 without TLS (h2c), knowing that the server supports it")
+	commandLine.BoolVarP(&http3, "http3", "", false, "Use HTTP/3 over QUIC")
 	commandLine.BoolVarP(&allowInsecure, "insecure", "k", false, "Allow connections with sites that have invalid SSL/TLS information")
+	commandLine.StringVarP(&key, "key", "", "", "Private key file in PEM format for the client certificate")
 	commandLine.BoolVarP(&followLocation, "location", "L", false, "Automatically follow redirects")
 	maxAddedRequests := commandLine.Int("max-added-requests", 10, "Maximum number of requests to add")
 	maxRedirect := commandLine.Int("max-redirs", 10, "Maximum number of redirects to follow")
+	commandLine.StringVarP(&maxTime, "max-time", "m", "", "Maximum time for all requests executed, including redirects and added requests")
 	commandLine.StringVarP(&method, "method", "X", "", "HTTP method to be used")
-	commandLine.StringVarP(&outputFile, "output", "o", "", "File to save the response")
+	commandLine.BoolVarP(&netrc, "netrc", "n", false, "Use credentials from the netrc file in the home directory when no auth is configured")
+	commandLine.StringVarP(&netrcFile, "netrc-file", "", "", "Path to the netrc file to use, implies --netrc")
+	commandLine.BoolVarP(&netrcOptional, "netrc-optional", "", false, "Like --netrc, but the netrc file is optional and credentials in the URL take precedence")
+	commandLine.StringVarP(&noProxy, "noproxy", "", "", "Comma separated hosts, domains and networks to access without proxy, * for all")
+	commandLine.StringVarP(&outputFile, "output", "o", "", "File to save the response body to, streamed without keeping it in memory")
+	commandLine.VarP(&pinnedPublicKeys, "pinnedpubkey", "", "Base64 encoded SHA-256 hash of a public key the server must present")
 	commandLine.StringVarP(&postProcessFile, "post-process", "", "", "Javascript file to post process the request/response")
-	commandLine.StringVarP(&fileToUpload, "upload-file", "T", "", "Path to the file to be uploaded")
+	commandLine.StringVarP(&proxyURL, "proxy", "x", "", "Proxy to use, e.g.: http://proxy:3128 or socks5://proxy:1080")
+	commandLine.StringVarP(&proxyUser, "proxy-user", "U", "", "User and password for the proxy, separated by a colon")
+	commandLine.BoolVarP(&remoteName, "remote-name", "O", false, "Save the response body to a file named from the Content-Disposition header or the URL")
+	commandLine.StringVarP(&requestTimeout, "request-timeout", "", "", "Maximum time for each request, including reading the response body")
+	commandLine.StringVarP(&responseTimeout, "response-timeout", "", "", "Maximum time to wait for the response headers after sending a request")
+	commandLine.IntVarP(&retryAttempts, "retry", "", 0, "Number of times to retry a request that failed")
+	commandLine.StringVarP(&retryDelay, "retry-delay", "", "", "Time to wait before the first retry, doubles for each retry after that (default 1s)")
+	commandLine.StringVarP(&retryMaxDelay, "retry-max-delay", "", "", "Maximum time to wait between retries, also when the server asks for more with Retry-After (default 30s)")
+	commandLine.BoolVarP(&retryNonIdempotent, "retry-non-idempotent", "", false, "Also retry methods that are not idempotent, like POST and PATCH")
+	commandLine.StringVarP(&retryOn, "retry-on", "", "", "Comma separated status codes and conditions (connect-error, timeout) to retry on (default 408,429,500,502,503,504,connect-error,timeout)")
+	commandLine.BoolVarP(&timings, "timings", "", false, "Show how long DNS lookup, connection, TLS handshake, first byte and transfer took")
+	commandLine.StringVarP(&tlsMaxVersion, "tls-max", "", "", "Maximum TLS version to use: 1.0, 1.1, 1.2 or 1.3")
+	commandLine.StringVarP(&tlsMinVersion, "tls-min", "", "", "Minimum TLS version to use: 1.0, 1.1, 1.2 or 1.3")
+	commandLine.StringVarP(&tlsTimeout, "tls-timeout", "", "", "Maximum time to complete the TLS handshake")
+	commandLine.StringVarP(&unixSocket, "unix-socket", "", "", "Unix socket to connect to instead of the host in the URL, e.g.: /var/run/docker.sock")
+	commandLine.StringVarP(&fileToUpload, "upload-file", "T", "", "File to stream as body, - for the standard input")
 	commandLine.VarP(&variables, "variable", "V", "Variables to be used on substitutions")
 
 	commandLine.Parse(args)
@@ -70,13 +132,116 @@ func ParseCommandLineOptions(args []string) (*CommandLineOptions, error) {
 
 	result.AllowInsecure = allowInsecure
 	result.Body = body
+	result.CACert = caCert
+	result.Cert = cert
 	result.FileToUpload = fileToUpload
+	if body != "" && fileToUpload != "" {
+		return result, errors.New("Cannot set body and upload a file at the same time")
+	}
+	if strings.HasPrefix(body, "@") {
+		result.Body = ""
+		result.FileToUpload = strings.TrimPrefix(body, "@")
+	}
+
+	if len(formFields) > 0 && (body != "" || fileToUpload != "") {
+		return result, errors.New("Cannot set body and form fields at the same time")
+	}
+	readsStdin := false
+	for _, formField := range formFields {
+		part, parseErr := formdata.ParsePart(formField)
+		if parseErr != nil {
+			return result, parseErr
+		}
+
+		if part.File == upload.Stdin {
+			if readsStdin {
+				return result, errors.New("Only one form field can be read from the standard input")
+			}
+			readsStdin = true
+		}
+		result.Form = append(result.Form, part)
+	}
+
+	httpVersions := []struct {
+		set     bool
+		version string
+	}{
+		{http1, protocol.HTTP1},
+		{http2, protocol.HTTP2},
+		{http2PriorKnowledge, protocol.HTTP2PriorKnowledge},
+		{http3, protocol.HTTP3},
+	}
+	for _, httpVersion := range httpVersions {
+		if !httpVersion.set {
+			continue
+		}
+		if result.HTTPVersion != "" {
+			return result, errors.New("Only one HTTP version can be set")
+		}
+		result.HTTPVersion = httpVersion.version
+	}
+
 	result.FollowLocation = followLocation
+	result.Key = key
 	result.MaxAddedRequests = *maxAddedRequests
 	result.MaxRedirect = *maxRedirect
 	result.Method = method
+	result.Netrc = netrc
+	result.NetrcFile = netrcFile
+	result.NetrcOptional = netrcOptional
 	result.OutputFile = outputFile
+	result.PinnedPublicKeys = pinnedPublicKeys
 	result.PostProcessFile = postProcessFile
+	result.RemoteName = remoteName
+	result.Proxy = proxy.Options{
+		NoProxy: proxy.SplitNoProxy(noProxy),
+		URL:     proxyURL,
+	}
+	if proxyUser != "" {
+		userAndPassword := strings.SplitN(proxyUser, ":", 2)
+		result.Proxy.Username = userAndPassword[0]
+		if len(userAndPassword) > 1 {
+			result.Proxy.Password = userAndPassword[1]
+		}
+	}
+	result.Timings = timings
+	result.TLSMaxVersion = tlsMaxVersion
+	result.TLSMinVersion = tlsMinVersion
+	result.UnixSocket = unixSocket
+
+	timeoutFlags := []struct {
+		name   string
+		value  string
+		target *time.Duration
+	}{
+		{"connect-timeout", connectTimeout, &result.ConnectTimeout},
+		{"max-time", maxTime, &result.MaxTime},
+		{"request-timeout", requestTimeout, &result.RequestTimeout},
+		{"response-timeout", responseTimeout, &result.ResponseTimeout},
+		{"retry-delay", retryDelay, &result.Retry.Delay},
+		{"retry-max-delay", retryMaxDelay, &result.Retry.MaxDelay},
+		{"tls-timeout", tlsTimeout, &result.TLSTimeout},
+	}
+
+	for _, timeoutFlag := range timeoutFlags {
+		duration, parseErr := timeout.ParseDuration(timeoutFlag.value)
+		if parseErr != nil {
+			return result, fmt.Errorf("Error while parsing --%s: %s", timeoutFlag.name, parseErr)
+		}
+		*timeoutFlag.target = duration
+	}
+
+	if retryAttempts < 0 {
+		return result, fmt.Errorf("Number of retries must not be negative: %d", retryAttempts)
+	}
+	result.Retry.Attempts = retryAttempts
+	result.Retry.NonIdempotent = retryNonIdempotent
+
+	retryConditions, retryOnErr := retry.ParseConditions(retryOn)
+	if retryOnErr != nil {
+		return result, fmt.Errorf("Error while parsing --retry-on: %s", retryOnErr)
+	}
+	result.Retry.On = retryConditions
 
 	parsedVariables, variableError := parseValues(variables)
 	result.Variables = parsedVariables
@@ -3,8 +3,10 @@ package cli
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/visola/go-http-cli/pkg/formdata"
 )
 
 var (
@@ -31,6 +33,20 @@ func TestParseCommandLineOptions(t *testing.T) {
 	t.Run("Parses multiple values for the same header", testParsesMultipleValuesForHeader)
 	t.Run("Parses header with = on the value", testParsesHeaderWithEqualOnValue)
 	t.Run("Fails to parse header with wrong separator", testFailToParseHeaderWithWrongSeparator)
+
+	t.Run("Parses netrc options", testParsesNetrcOptions)
+	t.Run("Parses timeouts", testParsesTimeouts)
+	t.Run("Fails to parse invalid timeout", testFailsToParseInvalidTimeout)
+	t.Run("Parses retry options", testParsesRetryOptions)
+	t.Run("Parses proxy options", testParsesProxyOptions)
+	t.Run("Parses HTTP version", testParsesHTTPVersion)
+	t.Run("Fails with more than one HTTP version", testFailsWithMoreThanOneHTTPVersion)
+	t.Run("Parses Unix socket", testParsesUnixSocket)
+	t.Run("Parses remote name", testParsesRemoteName)
+	t.Run("Parses data from file", testParsesDataFromFile)
+	t.Run("Fails with data and file to upload", testFailsWithDataAndFileToUpload)
+	t.Run("Parses form fields", testParsesFormFields)
+	t.Run("Fails with data and form fields", testFailsWithDataAndFormFields)
 }
 
 func testParsesFullURLCorrectly(t *testing.T) {
@@ -125,6 +141,128 @@ func testFailToParseHeaderWithWrongSeparator(t *testing.T) {
 	}
 }
 
+func testParsesNetrcOptions(t *testing.T) {
+	configuration, err := ParseCommandLineOptions([]string{"-n", testURL})
+	assert.Nil(t, err, "Should not return error")
+	assert.True(t, configuration.Netrc, "Should parse short netrc flag")
+
+	args := []string{"--netrc-optional", "--netrc-file", "/some/.netrc", testURL}
+	configuration, err = ParseCommandLineOptions(args)
+	assert.Nil(t, err, "Should not return error")
+	assert.False(t, configuration.Netrc, "Should not set netrc")
+	assert.True(t, configuration.NetrcOptional, "Should parse netrc optional")
+	assert.Equal(t, "/some/.netrc", configuration.NetrcFile, "Should parse netrc file")
+}
+
+func testParsesTimeouts(t *testing.T) {
+	args := []string{
+		"--connect-timeout", "2.5",
+		"--max-time", "1m",
+		"--request-timeout", "30",
+		"--response-timeout", "500ms",
+		"--tls-timeout", "3s",
+		testURL,
+	}
+	configuration, err := ParseCommandLineOptions(args)
+	assert.Nil(t, err, "Should not return error")
+	assert.Equal(t, 2500*time.Millisecond, configuration.ConnectTimeout, "Should parse seconds")
+	assert.Equal(t, time.Minute, configuration.MaxTime, "Should parse duration")
+	assert.Equal(t, 30*time.Second, configuration.RequestTimeout, "Should parse request timeout")
+	assert.Equal(t, 500*time.Millisecond, configuration.ResponseTimeout, "Should parse response timeout")
+	assert.Equal(t, 3*time.Second, configuration.TLSTimeout, "Should parse TLS timeout")
+}
+
+func testFailsToParseInvalidTimeout(t *testing.T) {
+	_, err := ParseCommandLineOptions([]string{"--connect-timeout", "soon", testURL})
+	assert.NotNil(t, err, "Should return error")
+}
+
+func testParsesRetryOptions(t *testing.T) {
+	args := []string{
+		"--retry", "3",
+		"--retry-delay", "0.5",
+		"--retry-max-delay", "10s",
+		"--retry-on", "502, 503,connect-error",
+		"--retry-non-idempotent",
+		testURL,
+	}
+	configuration, err := ParseCommandLineOptions(args)
+	assert.Nil(t, err, "Should not return error")
+	assert.Equal(t, 3, configuration.Retry.Attempts, "Should parse number of retries")
+	assert.Equal(t, 500*time.Millisecond, configuration.Retry.Delay, "Should parse retry delay")
+	assert.Equal(t, 10*time.Second, configuration.Retry.MaxDelay, "Should parse retry max delay")
+	assert.True(t, configuration.Retry.NonIdempotent, "Should parse retry non idempotent")
+	assert.Equal(t, []string{"502", "503", "connect-error"}, configuration.Retry.On, "Should parse retry conditions")
+
+	_, err = ParseCommandLineOptions([]string{"--retry-on", "sometimes", testURL})
+	assert.NotNil(t, err, "Should fail to parse invalid retry condition")
+}
+
+func testParsesProxyOptions(t *testing.T) {
+	args := []string{"-x", "socks5://localhost:1080", "-U", "user:pass:word", "--noproxy", "localhost, .internal", testURL}
+	configuration, err := ParseCommandLineOptions(args)
+	assert.Nil(t, err, "Should not return error")
+	assert.Equal(t, "socks5://localhost:1080", configuration.Proxy.URL, "Should parse proxy")
+	assert.Equal(t, "user", configuration.Proxy.Username, "Should parse proxy user")
+	assert.Equal(t, "pass:word", configuration.Proxy.Password, "Should split proxy password at the first colon")
+	assert.Equal(t, []string{"localhost", ".internal"}, configuration.Proxy.NoProxy, "Should parse no proxy list")
+}
+
+func testParsesHTTPVersion(t *testing.T) {
+	for flag, version := range map[string]string{"--http1.1": "1.1", "--http2": "2", "--http2-prior-knowledge": "2-prior-knowledge", "--http3": "3"} {
+		configuration, err := ParseCommandLineOptions([]string{flag, testURL})
+		assert.Nil(t, err, "Should not return error")
+		assert.Equal(t, version, configuration.HTTPVersion, "Should parse HTTP version from %s", flag)
+	}
+}
+
+func testFailsWithMoreThanOneHTTPVersion(t *testing.T) {
+	_, err := ParseCommandLineOptions([]string{"--http2", "--http3", testURL})
+	assert.NotNil(t, err, "Should not accept more than one HTTP version")
+}
+
+func testParsesUnixSocket(t *testing.T) {
+	configuration, err := ParseCommandLineOptions([]string{"--unix-socket", "/var/run/docker.sock", testURL})
+	assert.Nil(t, err, "Should not return error")
+	assert.Equal(t, "/var/run/docker.sock", configuration.UnixSocket, "Should parse Unix socket")
+}
+
+func testParsesRemoteName(t *testing.T) {
+	configuration, err := ParseCommandLineOptions([]string{"-O", testURL})
+	assert.Nil(t, err, "Should not return error")
+	assert.True(t, configuration.RemoteName, "Should parse remote name")
+}
+
+func testParsesDataFromFile(t *testing.T) {
+	configuration, err := ParseCommandLineOptions([]string{"-d", "@dump.sql", testURL})
+	assert.Nil(t, err, "Should not return error")
+	assert.Equal(t, "", configuration.Body, "Should not set data as body")
+	assert.Equal(t, "dump.sql", configuration.FileToUpload, "Should upload file")
+
+	configuration, err = ParseCommandLineOptions([]string{"-d", "@-", testURL})
+	assert.Nil(t, err, "Should not return error")
+	assert.Equal(t, "-", configuration.FileToUpload, "Should upload standard input")
+}
+
+func testFailsWithDataAndFileToUpload(t *testing.T) {
+	_, err := ParseCommandLineOptions([]string{"-d", "data", "-T", "dump.sql", testURL})
+	assert.NotNil(t, err, "Should not accept data and file to upload")
+}
+
+func testParsesFormFields(t *testing.T) {
+	configuration, err := ParseCommandLineOptions([]string{"-F", "name=John Doe", "--form", "photo=@me.jpg;type=image/jpeg", testURL})
+	assert.Nil(t, err, "Should not return error")
+	assert.Equal(t, []formdata.Part{
+		{Name: "name", Value: "John Doe"},
+		{ContentType: "image/jpeg", File: "me.jpg", Name: "photo"},
+	}, configuration.Form, "Should parse form fields in order")
+}
+
+func testFailsWithDataAndFormFields(t *testing.T) {
+	_, err := ParseCommandLineOptions([]string{"-d", "data", "-F", "name=value", testURL})
+	assert.NotNil(t, err, "Should not accept data and form fields")
+}
+
 func assertCorrectlyParsed(t *testing.T, configuration *CommandLineOptions, err error) {
 	assert.Nil(t, err, "Should not return error")
 	assert.NotNil(t, configuration, "Should return a configuration")
@@ -0,0 +1,73 @@
+package credential
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTTL is how long the output of a command is cached if no TTL is configured
+const DefaultTTL = 5 * time.Minute
+
+// Command is an external command that outputs a credential, e.g.: pass show api/prod
+type Command struct {
+	Command string
+	TTL     time.Duration // How long the output is cached, zero means it is not cached
+}
+
+type cachedOutput struct {
+	expiresAt time.Time
+	output    string
+}
+
+var (
+	now         = time.Now
+	outputCache = make(map[string]cachedOutput)
+	outputMutex = &sync.Mutex{}
+)
+
+// IsSet returns true if a command is configured
+func (command Command) IsSet() bool {
+	return command.Command != ""
+}
+
+// Run runs the command and returns what it printed to the standard output without the trailing
+// new line. The output is cached by command for the configured TTL.
+func (command Command) Run() (string, error) {
+	outputMutex.Lock()
+	defer outputMutex.Unlock()
+
+	if cached, exists := outputCache[command.Command]; exists && now().Before(cached.expiresAt) {
+		return cached.output, nil
+	}
+
+	var stdout, stderr bytes.Buffer
+	toRun := createShellCommand(command.Command)
+	toRun.Stdout = &stdout
+	toRun.Stderr = &stderr
+
+	if runErr := toRun.Run(); runErr != nil {
+		return "", fmt.Errorf("Error while running command '%s': %s %s", command.Command, runErr, strings.TrimSpace(stderr.String()))
+	}
+
+	output := strings.TrimRight(stdout.String(), "\r\n")
+	if command.TTL > 0 {
+		outputCache[command.Command] = cachedOutput{
+			expiresAt: now().Add(command.TTL),
+			output:    output,
+		}
+	}
+
+	return output, nil
+}
+
+func createShellCommand(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
@@ -0,0 +1,71 @@
+package credential
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommand(t *testing.T) {
+	t.Run("Returns output without trailing new line", testReturnsOutput)
+	t.Run("Caches output until TTL expires", testCachesOutput)
+	t.Run("Does not cache without TTL", testDoesNotCacheWithoutTTL)
+	t.Run("Fails when command fails", testFailsWhenCommandFails)
+}
+
+func testReturnsOutput(t *testing.T) {
+	output, err := Command{Command: "echo my-secret"}.Run()
+	assert.Nil(t, err, "Should run command")
+	assert.Equal(t, "my-secret", output, "Should return output")
+}
+
+func testCachesOutput(t *testing.T) {
+	toRun, countFile := countingCommand(t)
+	defer os.Remove(countFile)
+
+	command := Command{Command: toRun, TTL: time.Minute}
+
+	first, _ := command.Run()
+	second, _ := command.Run()
+	assert.Equal(t, first, second, "Should return cached output")
+
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Now().Add(time.Hour) }
+
+	third, _ := command.Run()
+	assert.NotEqual(t, first, third, "Should run command again after TTL expires")
+}
+
+func testDoesNotCacheWithoutTTL(t *testing.T) {
+	toRun, countFile := countingCommand(t)
+	defer os.Remove(countFile)
+
+	command := Command{Command: toRun}
+
+	first, _ := command.Run()
+	second, _ := command.Run()
+	assert.NotEqual(t, first, second, "Should run command every time")
+}
+
+func testFailsWhenCommandFails(t *testing.T) {
+	_, err := Command{Command: "echo not found >&2; exit 1"}.Run()
+	assert.NotNil(t, err, "Should return error")
+	assert.Contains(t, err.Error(), "not found", "Should include what the command printed to stderr")
+}
+
+// countingCommand creates a command that outputs how many times it was executed and the file
+// where the count is stored
+func countingCommand(t *testing.T) (string, string) {
+	countFile, err := ioutil.TempFile("", "count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	countFile.Close()
+
+	name := countFile.Name()
+	return fmt.Sprintf("echo run >> %s; wc -l < %s", name, name), name
+}
@@ -3,27 +3,45 @@ package daemon
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 
 	"github.com/visola/go-http-cli/pkg/ioutil"
+	"github.com/visola/go-http-cli/pkg/progress"
 	"github.com/visola/go-http-cli/pkg/request"
+	"github.com/visola/go-http-cli/pkg/secrets"
 	"github.com/visola/go-http-cli/pkg/session"
 )
 
-// ExecuteRequest request the daemon to execute a request
-func ExecuteRequest(executionContext request.ExecutionContext) (*RequestExecution, error) {
+// ExecuteRequest request the daemon to execute a request. Progress streaming request and
+// response bodies is passed to onProgress while the request executes.
+func ExecuteRequest(executionContext request.ExecutionContext, onProgress func(progress.Progress)) (*RequestExecution, error) {
 	dataAsBytes, marshalError := json.Marshal(executionContext)
 	if marshalError != nil {
 		return nil, marshalError
 	}
 
-	var requestExecution RequestExecution
-
-	if callDaemonError := callDaemon("/request", string(dataAsBytes), &requestExecution); callDaemonError != nil {
+	responseBody, callDaemonError := openDaemon("/request", string(dataAsBytes))
+	if callDaemonError != nil {
 		return nil, callDaemonError
 	}
-
-	return &requestExecution, nil
+	defer responseBody.Close()
+
+	decoder := json.NewDecoder(responseBody)
+	for {
+		var event RequestEvent
+		if decodeErr := decoder.Decode(&event); decodeErr != nil {
+			return nil, decodeErr
+		}
+
+		if event.Progress == nil {
+			return &event.RequestExecution, nil
+		}
+
+		if onProgress != nil {
+			onProgress(*event.Progress)
+		}
+	}
 }
 
 // Handshake connects and sends a handshake request to the daemon. Return the version of the daemon
@@ -52,19 +70,69 @@ func SetVariables(seVariablesRequest session.SetVariableRequest) error {
 	return nil
 }
 
+// SetTokens sends tokens to be stored in the daemon session
+func SetTokens(setTokensRequest session.SetTokensRequest) error {
+	dataAsBytes, marshalError := json.Marshal(setTokensRequest)
+	if marshalError != nil {
+		return marshalError
+	}
+
+	if callDaemonError := callDaemon("/tokens", string(dataAsBytes), nil); callDaemonError != nil {
+		return callDaemonError
+	}
+
+	return nil
+}
+
+// UnlockSecrets sends the passphrase to decrypt the secrets files to the daemon
+func UnlockSecrets(unlockRequest secrets.UnlockRequest) error {
+	dataAsBytes, marshalError := json.Marshal(unlockRequest)
+	if marshalError != nil {
+		return marshalError
+	}
+
+	if callDaemonError := callDaemon("/secrets/unlock", string(dataAsBytes), nil); callDaemonError != nil {
+		return callDaemonError
+	}
+
+	return nil
+}
+
 func callDaemon(path string, data string, unmarshalTo interface{}) error {
+	responseBody, openErr := openDaemon(path, data)
+	if openErr != nil {
+		return openErr
+	}
+	defer responseBody.Close()
+
+	if unmarshalTo != nil {
+		return json.NewDecoder(responseBody).Decode(unmarshalTo)
+	}
+
+	return nil
+}
+
+// openDaemon sends a request to the daemon and returns the body of the response
+func openDaemon(path string, data string) (io.ReadCloser, error) {
 	method := http.MethodPost
 
 	if data == "" {
 		method = http.MethodGet
 	}
 
-	url := "http://localhost:" + string(DaemonPort) + path
+	// Only the user that started the daemon can read the token
+	token, tokenErr := readDaemonToken()
+	if tokenErr != nil {
+		return nil, tokenErr
+	}
+
+	url := "http://" + DaemonHost + ":" + string(DaemonPort) + path
 	req, reqErr := http.NewRequest(method, url, nil)
 
 	if reqErr != nil {
-		return reqErr
+		return nil, reqErr
 	}
+	req.Header.Set(TokenHeader, token)
 
 	if data != "" {
 		req.Header.Add("Content-Type", "application/json")
@@ -75,16 +143,13 @@ func callDaemon(path string, data string, unmarshalTo interface{}) error {
 	response, responseErr := client.Do(req)
 
 	if responseErr != nil {
-		return responseErr
+		return nil, responseErr
 	}
 
 	if response.StatusCode != 200 {
-		panic(fmt.Sprintf("Daemon responded with unexpected status code: %d - %s\nURL: %s, Method: %s", response.StatusCode, response.Status, url, method))
+		response.Body.Close()
+		return nil, fmt.Errorf("Daemon responded with unexpected status code: %d - %s\nURL: %s, Method: %s", response.StatusCode, response.Status, url, method)
 	}
 
-	if unmarshalTo != nil {
-		return json.NewDecoder(response.Body).Decode(unmarshalTo)
-	}
-
-	return nil
+	return response.Body, nil
 }
@@ -1,12 +1,18 @@
 package daemon
 
 const (
+	// DaemonHost is the address the daemon listens on, only reachable from this machine
+	DaemonHost = "127.0.0.1"
+
 	// DaemonPort is the port the daemon uses to accept connections
 	DaemonPort = "4321"
 
 	// DaemonMajorVersion current version of the daemon
-	DaemonMajorVersion = 1
+	DaemonMajorVersion = 3
 
 	// DaemonMinorVersion current minor version of the daemon
 	DaemonMinorVersion = 0
+
+	// TokenHeader is the header clients send the token from the daemon token file in
+	TokenHeader = "X-Daemon-Token"
 )
@@ -1,6 +1,10 @@
 package daemon
 
-import "github.com/visola/go-http-cli/pkg/request"
+import (
+	"github.com/visola/go-http-cli/pkg/authorization"
+	"github.com/visola/go-http-cli/pkg/progress"
+	"github.com/visola/go-http-cli/pkg/request"
+)
 
 // HandshakeResponse is the response sent by the daemon when someone is checking if it's up.
 type HandshakeResponse struct {
@@ -12,4 +16,14 @@ type HandshakeResponse struct {
 type RequestExecution struct {
 	RequestResponses []request.ExecutedRequestResponse
 	ErrorMessage     string
+	LoginRequired    *authorization.LoginRequiredError // What the user needs to login with, if needed
+	SecretsLocked    bool
+	Timeout          string // Kind of timeout that caused the error, if any
+}
+
+// RequestEvent is sent by the daemon while executing a request. Events with progress are sent while
+// streaming request and response bodies, the last event has no progress and carries the execution.
+type RequestEvent struct {
+	Progress *progress.Progress
+	RequestExecution
 }
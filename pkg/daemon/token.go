@@ -0,0 +1,58 @@
+package daemon
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const tokenFile = "daemon.token"
+
+// WriteDaemonToken creates a random token and writes it to a file in the go-http-cli process dir that
+// only the current user can read. Clients have to send it with every request to the daemon.
+func WriteDaemonToken() (string, error) {
+	processDir, dirError := ensureProcessDirectory()
+	if dirError != nil {
+		return "", dirError
+	}
+
+	randomBytes := make([]byte, 32)
+	if _, randomErr := rand.Read(randomBytes); randomErr != nil {
+		return "", randomErr
+	}
+	token := hex.EncodeToString(randomBytes)
+
+	// Created again so that nobody else can have it open or have changed its permissions
+	tokenPath := processDir + "/" + tokenFile
+	if removeErr := os.Remove(tokenPath); removeErr != nil && !os.IsNotExist(removeErr) {
+		return "", removeErr
+	}
+
+	file, fileErr := os.OpenFile(tokenPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if fileErr != nil {
+		return "", fileErr
+	}
+	defer file.Close()
+
+	if _, writeErr := file.WriteString(token + "\n"); writeErr != nil {
+		return "", writeErr
+	}
+
+	return token, nil
+}
+
+func readDaemonToken() (string, error) {
+	processDir, dirError := ensureProcessDirectory()
+	if dirError != nil {
+		return "", dirError
+	}
+
+	buffer, readError := ioutil.ReadFile(processDir + "/" + tokenFile)
+	if readError != nil {
+		return "", readError
+	}
+
+	return strings.TrimSpace(string(buffer)), nil
+}
@@ -0,0 +1,108 @@
+package download
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/visola/go-http-cli/pkg/progress"
+)
+
+// Options configures where response bodies are saved
+type Options struct {
+	Directory  string // Directory where files named from the response are saved
+	File       string // File to save the response body to
+	RemoteName bool   // Name the file from the Content-Disposition header or from the URL
+}
+
+// Result is what was saved from a response body
+type Result struct {
+	File   string
+	SHA256 string
+	Size   int64
+}
+
+// Enabled returns true if response bodies need to be saved to a file
+func (options Options) Enabled() bool {
+	return options.File != "" || options.RemoteName
+}
+
+// FileName picks the file to save the response body to. When named from the response, only the
+// last part of the name is used so that a server can't write outside of the directory.
+func (options Options) FileName(contentDisposition string, requestURL string) (string, error) {
+	if options.File != "" {
+		return options.File, nil
+	}
+
+	name := ""
+	if _, params, parseErr := mime.ParseMediaType(contentDisposition); parseErr == nil {
+		name = params["filename"]
+	}
+
+	if name == "" {
+		parsedURL, parseErr := url.Parse(requestURL)
+		if parseErr != nil {
+			return "", parseErr
+		}
+		name = path.Base(parsedURL.Path)
+	}
+
+	name = filepath.Base(filepath.FromSlash(path.Base(name)))
+	if name == "." || name == ".." || name == "/" || name == string(filepath.Separator) {
+		return "", errors.New("Can't name the output file from the response, use -o to name it")
+	}
+
+	return filepath.Join(options.Directory, name), nil
+}
+
+// Overwrite returns true if the file can be overwritten, which is only when the user named it.
+// Files named from the response are never overwritten.
+func (options Options) Overwrite() bool {
+	return options.File != ""
+}
+
+// Save streams the body to a file, calculating its checksum and reporting progress as it goes. If
+// the file can't be overwritten and already exists, nothing is saved. If the body can't be read,
+// the partial file is removed.
+func Save(body io.Reader, file string, overwrite bool, total int64, onProgress func(progress.Progress)) (*Result, error) {
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+
+	output, createErr := os.OpenFile(file, flags, 0666)
+	if createErr != nil {
+		return nil, fmt.Errorf("Error while creating output file: %s", createErr)
+	}
+	defer output.Close()
+
+	hash := sha256.New()
+	reporter := progress.NewReporter(progress.Progress{File: file, Total: total}, onProgress)
+
+	size, copyErr := io.Copy(io.MultiWriter(output, hash, reporter), body)
+	if copyErr != nil {
+		output.Close()
+		os.Remove(file)
+		return nil, copyErr
+	}
+
+	if closeErr := output.Close(); closeErr != nil {
+		os.Remove(file)
+		return nil, closeErr
+	}
+
+	reporter.Done()
+
+	return &Result{
+		File:   file,
+		SHA256: hex.EncodeToString(hash.Sum(nil)),
+		Size:   size,
+	}, nil
+}
@@ -0,0 +1,124 @@
+package download
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visola/go-http-cli/pkg/progress"
+)
+
+func TestFileName(t *testing.T) {
+	t.Run("Uses output file", testUsesOutputFile)
+	t.Run("Names file from Content-Disposition", testNamesFileFromContentDisposition)
+	t.Run("Names file from URL", testNamesFileFromURL)
+	t.Run("Fails without a name", testFailsWithoutName)
+}
+
+func TestSave(t *testing.T) {
+	t.Run("Saves body", testSavesBody)
+	t.Run("Doesn't overwrite files named from the response", testDoesNotOverwriteRemoteNamedFiles)
+	t.Run("Removes partial file", testRemovesPartialFile)
+}
+
+func testSavesBody(t *testing.T) {
+	dir, dirErr := ioutil.TempDir("", "download")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	content := bytes.Repeat([]byte{0, 1, 2, 0xff, '\n'}, 1000)
+	file := filepath.Join(dir, "artifact.bin")
+
+	reported := make([]progress.Progress, 0)
+	result, err := Save(bytes.NewReader(content), file, true, int64(len(content)), func(transferred progress.Progress) {
+		reported = append(reported, transferred)
+	})
+	require.Nil(t, err, "Should save body")
+
+	saved, _ := ioutil.ReadFile(file)
+	assert.Equal(t, content, saved, "Should save binary data as it is")
+
+	hash := sha256.Sum256(content)
+	assert.Equal(t, Result{File: file, SHA256: hex.EncodeToString(hash[:]), Size: int64(len(content))}, *result, "Should return checksum and size")
+
+	require.True(t, len(reported) > 0, "Should report progress")
+	assert.Equal(t, progress.Progress{Done: true, File: file, Total: int64(len(content)), Transferred: int64(len(content))}, reported[len(reported)-1], "Should report when done")
+}
+
+func testDoesNotOverwriteRemoteNamedFiles(t *testing.T) {
+	dir, dirErr := ioutil.TempDir("", "download")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "artifact.bin")
+	require.Nil(t, ioutil.WriteFile(file, []byte("existing"), 0644), "Should create existing file")
+
+	_, err := Save(bytes.NewReader([]byte("new")), file, false, 3, func(progress.Progress) {})
+	assert.NotNil(t, err, "Should fail if file exists")
+
+	saved, _ := ioutil.ReadFile(file)
+	assert.Equal(t, "existing", string(saved), "Should keep existing file")
+
+	_, err = Save(bytes.NewReader([]byte("new")), file, true, 3, func(progress.Progress) {})
+	assert.Nil(t, err, "Should overwrite when allowed")
+
+	saved, _ = ioutil.ReadFile(file)
+	assert.Equal(t, "new", string(saved), "Should overwrite existing file")
+}
+
+func testRemovesPartialFile(t *testing.T) {
+	dir, dirErr := ioutil.TempDir("", "download")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "artifact.bin")
+	body := io.MultiReader(bytes.NewReader([]byte("partial")), iotest.ErrReader(errors.New("connection reset")))
+
+	_, err := Save(body, file, false, 100, func(progress.Progress) {})
+	assert.NotNil(t, err, "Should fail to save")
+
+	_, statErr := os.Stat(file)
+	assert.True(t, os.IsNotExist(statErr), "Should remove partial file")
+}
+
+func testUsesOutputFile(t *testing.T) {
+	options := Options{Directory: "/downloads", File: "/tmp/output.json", RemoteName: true}
+	name, err := options.FileName(`attachment; filename="report.pdf"`, "https://example.com/files/other.pdf")
+	assert.Nil(t, err, "Should pick file name")
+	assert.Equal(t, "/tmp/output.json", name, "Should use output file when set")
+}
+
+func testNamesFileFromContentDisposition(t *testing.T) {
+	options := Options{Directory: "/downloads", RemoteName: true}
+
+	name, err := options.FileName(`attachment; filename="report.pdf"`, "https://example.com/files/download?id=1")
+	assert.Nil(t, err, "Should pick file name")
+	assert.Equal(t, "/downloads/report.pdf", name, "Should use file name from Content-Disposition")
+
+	name, err = options.FileName(`attachment; filename="../../etc/passwd"`, "https://example.com/files/download")
+	assert.Nil(t, err, "Should pick file name")
+	assert.Equal(t, "/downloads/passwd", name, "Should not write outside the directory")
+}
+
+func testNamesFileFromURL(t *testing.T) {
+	options := Options{Directory: "/downloads", RemoteName: true}
+
+	name, err := options.FileName("", "https://example.com/files/artifact%201.tar.gz?version=2")
+	assert.Nil(t, err, "Should pick file name")
+	assert.Equal(t, "/downloads/artifact 1.tar.gz", name, "Should use last part of the path")
+}
+
+func testFailsWithoutName(t *testing.T) {
+	options := Options{Directory: "/downloads", RemoteName: true}
+	_, err := options.FileName("", "https://example.com/")
+	assert.NotNil(t, err, "Should fail without a name")
+}
@@ -0,0 +1,180 @@
+package formdata
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+
+	"github.com/visola/go-http-cli/pkg/upload"
+)
+
+// MimeType is the content type of multipart form bodies
+const MimeType = "multipart/form-data"
+
+// Content type of files when it can't be detected from the extension
+const defaultFileContentType = "application/octet-stream"
+
+// Escapes names in the Content-Disposition header the same way Go's multipart writer does
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// Part is a field in a multipart/form-data body
+type Part struct {
+	ContentType string // Content type of the part, detected from the extension for files if not set
+	File        string // File streamed as the value of the part
+	FileName    string // Name of the file sent to the server, defaults to the name of the file
+	Name        string
+	Value       string
+}
+
+// NewBoundary generates a random boundary to separate parts
+func NewBoundary() string {
+	return multipart.NewWriter(ioutil.Discard).Boundary()
+}
+
+// ParsePart parses a field like curl does: name=value for text and name=@file for files, where the
+// file can be followed by ;type=<content type> and ;filename=<name sent to the server>.
+func ParsePart(toParse string) (Part, error) {
+	separator := strings.Index(toParse, "=")
+	if separator <= 0 {
+		return Part{}, fmt.Errorf("Invalid form field '%s', must be name=value or name=@file", toParse)
+	}
+
+	part := Part{Name: toParse[:separator]}
+	value := toParse[separator+1:]
+	if !strings.HasPrefix(value, "@") {
+		part.Value = value
+		return part, nil
+	}
+
+	options := strings.Split(strings.TrimPrefix(value, "@"), ";")
+	part.File = options[0]
+	if part.File == "" {
+		return Part{}, fmt.Errorf("Missing file in form field '%s'", toParse)
+	}
+
+	for _, option := range options[1:] {
+		keyAndValue := strings.SplitN(option, "=", 2)
+		if len(keyAndValue) != 2 {
+			return Part{}, fmt.Errorf("Invalid option '%s' in form field '%s'", option, toParse)
+		}
+
+		switch strings.ToLower(strings.TrimSpace(keyAndValue[0])) {
+		case "filename":
+			part.FileName = keyAndValue[1]
+		case "type":
+			part.ContentType = keyAndValue[1]
+		default:
+			return Part{}, fmt.Errorf("Unknown option '%s' in form field '%s', must be type or filename", keyAndValue[0], toParse)
+		}
+	}
+
+	return part, nil
+}
+
+// Body builds a multipart/form-data body with the parts separated by the boundary. Files are streamed
+// when the body is read. Returns the size of the body or -1 if any file has an unknown size.
+func Body(parts []Part, boundary string) (io.ReadCloser, int64, error) {
+	body := &multiReadCloser{}
+	size := int64(0)
+	unknownSize := false
+
+	headers := &bytes.Buffer{}
+	writer := multipart.NewWriter(headers)
+	if boundaryErr := writer.SetBoundary(boundary); boundaryErr != nil {
+		return nil, 0, fmt.Errorf("Invalid multipart boundary '%s': %s", boundary, boundaryErr)
+	}
+
+	// Everything that's not a value goes through the writer, which only adds headers and boundaries
+	flushHeaders := func() {
+		size += int64(headers.Len())
+		body.readers = append(body.readers, bytes.NewReader(append([]byte{}, headers.Bytes()...)))
+		headers.Reset()
+	}
+
+	for _, part := range parts {
+		writer.CreatePart(part.header())
+		flushHeaders()
+
+		if part.File == "" {
+			size += int64(len(part.Value))
+			body.readers = append(body.readers, strings.NewReader(part.Value))
+			continue
+		}
+
+		file, fileSize, openErr := upload.Open(part.File)
+		if openErr != nil {
+			body.Close()
+			return nil, 0, openErr
+		}
+		body.closers = append(body.closers, file)
+		body.readers = append(body.readers, file)
+
+		if fileSize < 0 {
+			unknownSize = true
+		}
+		size += fileSize
+	}
+
+	writer.Close()
+	flushHeaders()
+
+	if unknownSize {
+		return body, -1, nil
+	}
+	return body, size, nil
+}
+
+// header creates the MIME header for the part
+func (part Part) header() textproto.MIMEHeader {
+	header := make(textproto.MIMEHeader)
+	disposition := fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(part.Name))
+
+	contentType := part.ContentType
+	if part.File != "" {
+		fileName := part.FileName
+		if fileName == "" {
+			fileName = filepath.Base(part.File)
+		}
+		disposition += fmt.Sprintf(`; filename="%s"`, quoteEscaper.Replace(fileName))
+
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(part.File))
+		}
+		if contentType == "" {
+			contentType = defaultFileContentType
+		}
+	}
+
+	header.Set("Content-Disposition", disposition)
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	return header
+}
+
+// multiReadCloser reads from all readers in sequence and closes the files when closed
+type multiReadCloser struct {
+	closers []io.Closer
+	reader  io.Reader
+	readers []io.Reader
+}
+
+func (body *multiReadCloser) Read(data []byte) (int, error) {
+	if body.reader == nil {
+		body.reader = io.MultiReader(body.readers...)
+	}
+	return body.reader.Read(data)
+}
+
+func (body *multiReadCloser) Close() error {
+	for _, closer := range body.closers {
+		closer.Close()
+	}
+	return nil
+}
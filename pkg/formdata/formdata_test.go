@@ -0,0 +1,72 @@
+package formdata
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePart(t *testing.T) {
+	t.Run("Parses text field", testParsesTextField)
+	t.Run("Parses file with options", testParsesFileWithOptions)
+	t.Run("Fails with invalid field", testFailsWithInvalidField)
+}
+
+func TestBody(t *testing.T) {
+	dir, dirErr := ioutil.TempDir("", "formdata")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "chart.png")
+	require.Nil(t, ioutil.WriteFile(file, []byte("not really a PNG"), 0600), "Should write file")
+
+	boundary := NewBoundary()
+	body, size, err := Body([]Part{
+		{Name: "title", Value: "Monthly \"report\""},
+		{File: file, Name: "report"},
+		{ContentType: "text/plain", File: file, FileName: "other.txt", Name: "copy"},
+	}, boundary)
+	require.Nil(t, err, "Should build body")
+
+	content, readErr := ioutil.ReadAll(body)
+	require.Nil(t, readErr, "Should read body")
+	body.Close()
+	assert.Equal(t, int64(len(content)), size, "Should calculate size of body")
+
+	form, parseErr := multipart.NewReader(bytes.NewReader(content), boundary).ReadForm(1024)
+	require.Nil(t, parseErr, "Should be a valid multipart form")
+
+	assert.Equal(t, []string{"Monthly \"report\""}, form.Value["title"], "Should send text field")
+	require.Equal(t, 1, len(form.File["report"]), "Should send file")
+	assert.Equal(t, "chart.png", form.File["report"][0].Filename, "Should name file after the file sent")
+	assert.Equal(t, "image/png", form.File["report"][0].Header.Get("Content-Type"), "Should detect content type from extension")
+
+	require.Equal(t, 1, len(form.File["copy"]), "Should send file with options")
+	assert.Equal(t, "other.txt", form.File["copy"][0].Filename, "Should use file name from options")
+	assert.Equal(t, "text/plain", form.File["copy"][0].Header.Get("Content-Type"), "Should use content type from options")
+}
+
+func testParsesTextField(t *testing.T) {
+	part, err := ParsePart("message=a=b;c")
+	assert.Nil(t, err, "Should parse field")
+	assert.Equal(t, Part{Name: "message", Value: "a=b;c"}, part, "Should keep everything after the first = as value")
+}
+
+func testParsesFileWithOptions(t *testing.T) {
+	part, err := ParsePart("photo=@images/me.jpg;type=image/jpeg;filename=profile.jpg")
+	assert.Nil(t, err, "Should parse field")
+	assert.Equal(t, Part{ContentType: "image/jpeg", File: "images/me.jpg", FileName: "profile.jpg", Name: "photo"}, part, "Should parse file and options")
+}
+
+func testFailsWithInvalidField(t *testing.T) {
+	for _, field := range []string{"no-value", "=value", "photo=@", "photo=@me.jpg;size=10", "photo=@me.jpg;type"} {
+		_, err := ParsePart(field)
+		assert.NotNil(t, err, "Should fail to parse %s", field)
+	}
+}
@@ -14,6 +14,11 @@ func (bb *CloseableByteBuffer) Close() error {
 	return nil
 }
 
+// CreateCloseableBuffer creates a CloseableByteBuffer from bytes
+func CreateCloseableBuffer(data []byte) *CloseableByteBuffer {
+	return &CloseableByteBuffer{bytes.NewBuffer(data)}
+}
+
 // CreateCloseableBufferString creates a CloseableByteBuffer from a string
 func CreateCloseableBufferString(data string) *CloseableByteBuffer {
 	return &CloseableByteBuffer{bytes.NewBufferString(data)}
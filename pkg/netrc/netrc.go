@@ -0,0 +1,147 @@
+package netrc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Machine is an entry in a netrc file
+type Machine struct {
+	Account  string
+	Default  bool
+	Login    string
+	Name     string
+	Password string
+}
+
+// Options configures how credentials are loaded from a netrc file, following the same semantics
+// as curl
+type Options struct {
+	// Enabled is true if credentials should be loaded from the netrc file
+	Enabled bool
+
+	// File is the path to the netrc file, if empty the one in the user home directory is used
+	File string
+
+	// Optional makes a missing file not an error and gives precedence to credentials in the URL
+	Optional bool
+}
+
+// DefaultFile returns the path to the netrc file in the user home directory
+func DefaultFile() (string, error) {
+	homeDir, homeErr := os.UserHomeDir()
+	if homeErr != nil {
+		return "", homeErr
+	}
+
+	if runtime.GOOS == "windows" {
+		return filepath.Join(homeDir, "_netrc"), nil
+	}
+	return filepath.Join(homeDir, ".netrc"), nil
+}
+
+// FindMachine loads the netrc file configured in the options and returns the entry for the host.
+// If no entry matches the host, the default entry is returned. Returns nil if netrc is not enabled
+// or if no entry is found.
+func FindMachine(options Options, host string) (*Machine, error) {
+	if !options.Enabled {
+		return nil, nil
+	}
+
+	file := options.File
+	if file == "" {
+		var fileErr error
+		if file, fileErr = DefaultFile(); fileErr != nil {
+			return nil, fileErr
+		}
+	}
+
+	content, readErr := ioutil.ReadFile(file)
+	if readErr != nil {
+		if options.Optional && os.IsNotExist(readErr) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("Error while reading netrc file: %s", readErr)
+	}
+
+	var defaultMachine *Machine
+	machines := Parse(string(content))
+	for index := range machines {
+		if machines[index].Default {
+			defaultMachine = &machines[index]
+			continue
+		}
+
+		if strings.EqualFold(machines[index].Name, host) {
+			return &machines[index], nil
+		}
+	}
+
+	return defaultMachine, nil
+}
+
+// Parse parses the content of a netrc file. Macro definitions are skipped.
+func Parse(content string) []Machine {
+	result := make([]Machine, 0)
+	var current *Machine
+
+	lines := strings.Split(strings.Replace(content, "\r\n", "\n", -1), "\n")
+	for lineIndex := 0; lineIndex < len(lines); lineIndex++ {
+		tokens := strings.Fields(lines[lineIndex])
+		for tokenIndex := 0; tokenIndex < len(tokens); tokenIndex++ {
+			token := tokens[tokenIndex]
+			if strings.HasPrefix(token, "#") {
+				break
+			}
+
+			value := ""
+			if tokenIndex+1 < len(tokens) {
+				value = tokens[tokenIndex+1]
+			}
+
+			switch token {
+			case "machine":
+				result = appendMachine(result, current)
+				current = &Machine{Name: value}
+				tokenIndex++
+			case "default":
+				result = appendMachine(result, current)
+				current = &Machine{Default: true}
+			case "login":
+				if current != nil {
+					current.Login = value
+				}
+				tokenIndex++
+			case "password":
+				if current != nil {
+					current.Password = value
+				}
+				tokenIndex++
+			case "account":
+				if current != nil {
+					current.Account = value
+				}
+				tokenIndex++
+			case "macdef":
+				// Macro definitions end with an empty line
+				for lineIndex+1 < len(lines) && strings.TrimSpace(lines[lineIndex+1]) != "" {
+					lineIndex++
+				}
+				tokenIndex = len(tokens)
+			}
+		}
+	}
+
+	return appendMachine(result, current)
+}
+
+func appendMachine(machines []Machine, machine *Machine) []Machine {
+	if machine == nil {
+		return machines
+	}
+	return append(machines, *machine)
+}
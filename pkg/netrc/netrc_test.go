@@ -0,0 +1,78 @@
+package netrc
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testNetrc = `# Comments are ignored
+machine api.example.com login someone password s3cr3t
+machine other.example.com
+  login other
+  password otherPassword
+  account otherAccount
+
+macdef init
+  cd /pub
+  machine ignored.example.com login ignored password ignored
+
+default login anonymous password guest
+`
+
+func TestNetrc(t *testing.T) {
+	t.Run("Parses machines", testParsesMachines)
+	t.Run("Finds machine for host", testFindsMachineForHost)
+	t.Run("Fails with missing file unless optional", testFailsWithMissingFile)
+}
+
+func testParsesMachines(t *testing.T) {
+	machines := Parse(testNetrc)
+
+	assert.Equal(t, []Machine{
+		{Name: "api.example.com", Login: "someone", Password: "s3cr3t"},
+		{Name: "other.example.com", Login: "other", Password: "otherPassword", Account: "otherAccount"},
+		{Default: true, Login: "anonymous", Password: "guest"},
+	}, machines, "Should parse machines in single and multiple lines, skipping macros")
+}
+
+func testFindsMachineForHost(t *testing.T) {
+	netrcFile := writeTestNetrc(t)
+	defer os.RemoveAll(filepath.Dir(netrcFile))
+
+	options := Options{Enabled: true, File: netrcFile}
+
+	machine, err := FindMachine(options, "API.example.com")
+	assert.Nil(t, err, "Should find machine")
+	assert.Equal(t, "someone", machine.Login, "Should match host ignoring case")
+
+	machine, _ = FindMachine(options, "unknown.example.com")
+	assert.Equal(t, "anonymous", machine.Login, "Should fallback to default")
+
+	machine, _ = FindMachine(Options{File: netrcFile}, "api.example.com")
+	assert.Nil(t, machine, "Should not load anything if not enabled")
+}
+
+func testFailsWithMissingFile(t *testing.T) {
+	missingFile := filepath.Join(os.TempDir(), "go-http-cli-missing-netrc")
+
+	_, err := FindMachine(Options{Enabled: true, File: missingFile}, "api.example.com")
+	assert.NotNil(t, err, "Should fail if file is required")
+
+	machine, err := FindMachine(Options{Enabled: true, File: missingFile, Optional: true}, "api.example.com")
+	assert.Nil(t, err, "Should not fail if file is optional")
+	assert.Nil(t, machine, "Should not find machine")
+}
+
+func writeTestNetrc(t *testing.T) string {
+	dir, dirErr := ioutil.TempDir("", "netrc")
+	require.Nil(t, dirErr, "Should create temp dir")
+
+	netrcFile := filepath.Join(dir, ".netrc")
+	require.Nil(t, ioutil.WriteFile(netrcFile, []byte(testNetrc), 0600), "Should write netrc file")
+	return netrcFile
+}
@@ -0,0 +1,72 @@
+package output
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/visola/go-http-cli/pkg/download"
+	"github.com/visola/go-http-cli/pkg/progress"
+	"golang.org/x/term"
+)
+
+const progressBarWidth = 30
+
+// PrintDownload outputs where a response body was saved and its checksum
+func PrintDownload(result download.Result) {
+	color.Green("Saved %s to %s\n", formatSize(result.Size), result.File)
+	color.New(color.Bold).Printf("SHA-256: ")
+	fmt.Println(result.SHA256)
+}
+
+// PrintProgress outputs a progress bar for a body being sent or saved. It's only shown if the
+// standard error is a terminal, so that it doesn't end up in redirected output.
+func PrintProgress(transfer progress.Progress) {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		return
+	}
+
+	label := "Downloading"
+	if transfer.Upload {
+		label = "Uploading"
+	}
+
+	line := fmt.Sprintf("%-11s %s", label, formatSize(transfer.Transferred))
+	if transfer.Total > 0 {
+		done := int(transfer.Transferred * progressBarWidth / transfer.Total)
+		if done > progressBarWidth {
+			done = progressBarWidth
+		}
+		line = fmt.Sprintf(
+			"%-11s [%s%s] %3d%% %s / %s",
+			label,
+			strings.Repeat("=", done),
+			strings.Repeat(" ", progressBarWidth-done),
+			transfer.Transferred*100/transfer.Total,
+			formatSize(transfer.Transferred),
+			formatSize(transfer.Total),
+		)
+	}
+
+	// Clear what's left from the previous line, which can be longer
+	fmt.Fprintf(os.Stderr, "\r%-80s", line)
+	if transfer.Done {
+		fmt.Fprintln(os.Stderr, "")
+	}
+}
+
+func formatSize(size int64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	value := float64(size)
+	unit := 0
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if unit == 0 {
+		return fmt.Sprintf("%d %s", size, units[unit])
+	}
+	return fmt.Sprintf("%.1f %s", value, units[unit])
+}
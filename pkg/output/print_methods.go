@@ -1,11 +1,14 @@
 package output
 
 import (
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"net/url"
 	"sort"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/fatih/color"
 	"github.com/visola/go-http-cli/pkg/request"
@@ -44,7 +47,19 @@ func PrintRequest(req request.Request) {
 
 	printHeaders(req.Headers)
 	printCookies(req.Cookies)
-	printBody(req.Body, ">>")
+	printBody(string(req.Body), ">>")
+
+	if req.BodyFile != "" {
+		printBody(fmt.Sprintf("(body streamed from %s)", req.BodyFile), ">>")
+	}
+
+	for _, part := range req.Multipart {
+		if part.File != "" {
+			printBody(fmt.Sprintf("%s=(streamed from %s)", part.Name, part.File), ">>")
+		} else {
+			printBody(fmt.Sprintf("%s=%s", part.Name, part.Value), ">>")
+		}
+	}
 }
 
 // PrintResponse outputs a http.Response
@@ -58,10 +73,18 @@ func PrintResponse(response request.Response) {
 
 	printSummaryFunction("%s %s\n", response.Status, response.Protocol)
 	printHeaders(response.Headers)
-	printBody(response.Body, "<<")
+	printBody(string(response.Body), "<<")
 }
 
+// How many bytes of a binary body are shown in the hex preview
+const binaryPreviewSize = 64
+
 func printBody(body string, linePrefix string) {
+	if isBinary(body) {
+		printBinaryBody(body, linePrefix)
+		return
+	}
+
 	if body != "" {
 		bodyColor := color.New(color.Bold).PrintfFunc()
 		for _, line := range strings.Split(body, "\n") {
@@ -70,6 +93,39 @@ func printBody(body string, linePrefix string) {
 	}
 }
 
+// printBinaryBody prints a summary of the body instead of dumping it to the terminal
+func printBinaryBody(body string, linePrefix string) {
+	bodyColor := color.New(color.Bold).PrintfFunc()
+	bodyColor("%s (binary body, %s, %s)\n", linePrefix, formatSize(int64(len(body))), http.DetectContentType([]byte(body)))
+
+	preview := body
+	if len(preview) > binaryPreviewSize {
+		preview = preview[:binaryPreviewSize]
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(hex.Dump([]byte(preview)), "\n"), "\n") {
+		bodyColor("%s %s\n", linePrefix, line)
+	}
+
+	if len(body) > binaryPreviewSize {
+		bodyColor("%s ...\n", linePrefix)
+	}
+}
+
+// isBinary checks if the body is not valid UTF-8 or has control characters that aren't usually in text
+func isBinary(body string) bool {
+	if !utf8.ValidString(body) {
+		return true
+	}
+
+	for _, char := range body {
+		if unicode.IsControl(char) && !strings.ContainsRune("\b\t\n\v\f\r\x1b", char) {
+			return true
+		}
+	}
+	return false
+}
+
 func printCookies(cookies []*http.Cookie) {
 	if len(cookies) > 0 {
 		sentCookieKeyColor := color.New(color.Bold, color.FgBlue).PrintfFunc()
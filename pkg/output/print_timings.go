@@ -0,0 +1,37 @@
+package output
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/visola/go-http-cli/pkg/request"
+)
+
+// PrintTimings outputs how long each phase of a request took
+func PrintTimings(timings request.Timings) {
+	phaseColor := color.New(color.Bold).PrintfFunc()
+
+	connect := timings.Connect.Round(time.Microsecond).String()
+	if timings.ReusedConnection {
+		connect += " (reused connection)"
+	}
+
+	phases := []struct {
+		name     string
+		duration string
+	}{
+		{"DNS lookup", timings.DNSLookup.Round(time.Microsecond).String()},
+		{"Connect", connect},
+		{"TLS handshake", timings.TLSHandshake.Round(time.Microsecond).String()},
+		{"First byte", timings.FirstByte.Round(time.Microsecond).String()},
+		{"Transfer", timings.Transfer.Round(time.Microsecond).String()},
+		{"Total", timings.Total.Round(time.Microsecond).String()},
+	}
+
+	color.Green("Timings:")
+	for _, phase := range phases {
+		phaseColor("  %-15s", phase.name+":")
+		fmt.Println(phase.duration)
+	}
+}
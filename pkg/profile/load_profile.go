@@ -31,9 +31,19 @@ func LoadAndMergeProfiles(profileNames []string) (Options, error) {
 
 // LoadProfile loads Options for a specific profile by name.
 func LoadProfile(profileName string) (loadedOptions Options, err error) {
+	fileNameWithExtension, findErr := findProfileFile(profileName)
+	if findErr != nil {
+		return loadedOptions, findErr
+	}
+
+	return readFrom(fileNameWithExtension)
+}
+
+// findProfileFile finds the file for a profile by name
+func findProfileFile(profileName string) (string, error) {
 	profilesDir, profilesDirErr := GetProfilesDir()
 	if profilesDirErr != nil {
-		return loadedOptions, profilesDirErr
+		return "", profilesDirErr
 	}
 
 	fileName := profilesDir + "/" + profileName
@@ -46,12 +56,12 @@ func LoadProfile(profileName string) (loadedOptions Options, err error) {
 			fileNameWithExtension = fileName + yamlExtension
 			// If file still doesn't exist
 			if _, err := os.Stat(fileNameWithExtension); os.IsNotExist(err) {
-				return loadedOptions, errors.New("Configuration file does not exist: " + fileNameWithExtension)
+				return "", errors.New("Configuration file does not exist: " + fileNameWithExtension)
 			}
 		}
 	}
 
-	return readFrom(fileNameWithExtension)
+	return fileNameWithExtension, nil
 }
 
 func readFrom(pathToYamlFile string) (finalOptions Options, err error) {
@@ -79,12 +89,21 @@ func readFrom(pathToYamlFile string) (finalOptions Options, err error) {
 		importedOptions = append(importedOptions, imported)
 	}
 
-	readOption, conversionErr := loadedOptions.toOptions()
+	secretOptions, secretsLocked, secretsErr := loadSecrets(pathToYamlFile)
+	if secretsErr != nil {
+		return finalOptions, secretsErr
+	}
+
+	readOption, conversionErr := loadedOptions.toOptions(secretsLocked || secretOptions != nil)
 	if conversionErr != nil {
 		return finalOptions, conversionErr
 	}
+	readOption.SecretsLocked = secretsLocked
 
 	importedOptions = append(importedOptions, *readOption)
+	if secretOptions != nil {
+		importedOptions = append(importedOptions, *secretOptions)
+	}
 
 	// Add the source from where each named request was loaded
 	for name, namedRequest := range readOption.NamedRequest {
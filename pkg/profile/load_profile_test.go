@@ -2,8 +2,10 @@ package profile
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/visola/go-http-cli/pkg/credential"
 )
 
 func TestLoadProfile(t *testing.T) {
@@ -29,6 +31,9 @@ func TestLoadProfile(t *testing.T) {
 	profileContent = profileContent + "\n  testRequest:"
 	profileContent = profileContent + "\n    headers:"
 	profileContent = profileContent + "\n      X-Some-Header: '1234-1234-1234'"
+	profileContent = profileContent + "\n    auth:"
+	profileContent = profileContent + "\n      type: bearer"
+	profileContent = profileContent + "\n      token: '{accessToken}'"
 	CreateTestProfile(profileName, profileContent, tempProfilesDir)
 
 	profile, requestErr := LoadProfile(profileName)
@@ -40,10 +45,11 @@ func TestLoadProfile(t *testing.T) {
 
 	assert.Equal(t, "http://www.someserver.com", profile.BaseURL, "Should set the base URL correctly")
 
-	authValues, exists := profile.Headers["Authorization"]
-	assert.True(t, exists, "Should have Authorization header")
-	assert.Equal(t, 1, len(authValues), "Should have only one value")
-	assert.Equal(t, "Basic bXlVc2VybmFtZTpteVBhc3N3b3Jk", authValues[0], "Should set the auth value correctly")
+	_, hasAuthHeader := profile.Headers["Authorization"]
+	assert.False(t, hasAuthHeader, "Should not generate the Authorization header when loading")
+	assert.Equal(t, "basic", profile.Auth.AuthorizationType, "Should load auth type")
+	assert.Equal(t, "myUsername", profile.Auth.Username, "Should load username")
+	assert.Equal(t, "myPassword", profile.Auth.Password, "Should load password")
 
 	contentTypeValue, exists := profile.Headers["Content-Type"]
 	assert.True(t, exists, "Should have Content-Type header")
@@ -54,4 +60,33 @@ func TestLoadProfile(t *testing.T) {
 	assert.True(t, hasRequest, "Should load request data")
 	assert.Equal(t, 1, len(testRequest.Headers["X-Some-Header"]), "Should load header correctly")
 	assert.Equal(t, "1234-1234-1234", testRequest.Headers["X-Some-Header"][0], "Should load header correctly")
+	assert.Equal(t, "bearer", testRequest.Auth.AuthorizationType, "Should load auth for named request")
+	assert.Equal(t, "{accessToken}", testRequest.Auth.Token, "Should keep variables in auth to replace them later")
+}
+
+func TestLoadProfileWithCommands(t *testing.T) {
+	tempProfilesDir := SetupTestProfilesDir()
+
+	profileContent := `auth:
+  type: basic
+  username: myUsername
+  passwordCommand: pass show api/prod
+variables:
+  plain: someValue
+  apiKey:
+    command: pass show api/key
+    ttl: 1m
+`
+	CreateTestProfile("commands", profileContent, tempProfilesDir)
+
+	profile, loadErr := LoadProfile("commands")
+	assert.Nil(t, loadErr, "Should load profile correctly")
+
+	_, hasAuthHeader := profile.Headers["Authorization"]
+	assert.False(t, hasAuthHeader, "Should not run command when loading profile")
+	assert.Equal(t, "pass show api/prod", profile.Auth.PasswordCommand.Command, "Should load password command")
+	assert.Equal(t, credential.DefaultTTL, profile.Auth.PasswordCommand.TTL, "Should use default TTL")
+
+	assert.Equal(t, map[string]string{"plain": "someValue"}, profile.Variables, "Should load plain variables")
+	assert.Equal(t, credential.Command{Command: "pass show api/key", TTL: time.Minute}, profile.VariableCommands["apiKey"], "Should load variable command")
 }
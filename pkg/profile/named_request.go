@@ -1,20 +1,34 @@
 package profile
 
 import (
-	"io/ioutil"
 	"path/filepath"
+
+	"github.com/visola/go-http-cli/pkg/authorization"
+	"github.com/visola/go-http-cli/pkg/formdata"
+	"github.com/visola/go-http-cli/pkg/proxy"
+	"github.com/visola/go-http-cli/pkg/retry"
+	"github.com/visola/go-http-cli/pkg/timeout"
+	"github.com/visola/go-http-cli/pkg/tlsconfig"
 )
 
 // NamedRequest is a representation of a request that can be loaded from a profile.
 type NamedRequest struct {
 	AllowInsecure     bool
+	Auth              authorization.Authorization
 	Body              string
 	FileToUpload      string
 	Headers           map[string][]string
+	HTTPVersion       string
 	Method            string
+	Multipart         []formdata.Part
 	Name              string
 	PostProcessScript string
+	Proxy             proxy.Options
+	Retry             retry.Options
 	Source            string // File where this request was loaded from
+	Timeouts          timeout.Options
+	TLS               tlsconfig.Options
+	UnixSocket        string
 	URL               string
 	Values            map[string][]string
 }
@@ -24,34 +38,33 @@ func (req NamedRequest) GetAllowInsecure() bool {
 	return req.AllowInsecure
 }
 
+// GetAuth returns the authorization for this NamedRequest with paths relative to the profiles dir resolved
+func (req NamedRequest) GetAuth() (authorization.Authorization, error) {
+	return resolveAuthPaths(req.Auth)
+}
+
 // GetBody returns the body for this NamedRequest
 func (req NamedRequest) GetBody() (string, error) {
-	if req.Body != "" {
-		return req.Body, nil
-	}
+	return req.Body, nil
+}
 
-	// If there's a file to upload from profile, load it
-	if req.FileToUpload != "" {
-		path := req.FileToUpload
-
-		// If not absolute, it's relative to the profiles dir
-		if !filepath.IsAbs(path) {
-			profileDir, profileDirError := GetProfilesDir()
-			if profileDirError != nil {
-				return "", profileDirError
-			}
-			path = filepath.Join(profileDir, req.FileToUpload)
-		}
+// GetBodyFile returns the file to stream as body for this NamedRequest, relative to the profiles dir
+// if not absolute. The body takes precedence if both are set.
+func (req NamedRequest) GetBodyFile() (string, error) {
+	if req.Body != "" || req.FileToUpload == "" {
+		return "", nil
+	}
 
-		data, loadErr := ioutil.ReadFile(path)
-		if loadErr != nil {
-			return "", loadErr
-		}
+	if filepath.IsAbs(req.FileToUpload) {
+		return req.FileToUpload, nil
+	}
 
-		return string(data), nil
+	profileDir, profileDirError := GetProfilesDir()
+	if profileDirError != nil {
+		return "", profileDirError
 	}
 
-	return "", nil
+	return filepath.Join(profileDir, req.FileToUpload), nil
 }
 
 // GetHeaders returns the headers for this NamedRequest
@@ -59,12 +72,108 @@ func (req NamedRequest) GetHeaders() map[string][]string {
 	return req.Headers
 }
 
+// GetHTTPVersion returns the version of the protocol to use for this NamedRequest
+func (req NamedRequest) GetHTTPVersion() string {
+	return req.HTTPVersion
+}
+
 // GetMethod return the HTTP method for this NamedRequest
 func (req NamedRequest) GetMethod() string {
 	return req.Method
 }
 
+// GetMultipart returns the parts of the multipart form body for this NamedRequest with files
+// relative to the profiles dir resolved
+func (req NamedRequest) GetMultipart() ([]formdata.Part, error) {
+	if len(req.Multipart) == 0 {
+		return nil, nil
+	}
+
+	profileDir, profileDirError := GetProfilesDir()
+	if profileDirError != nil {
+		return nil, profileDirError
+	}
+
+	parts := make([]formdata.Part, len(req.Multipart))
+	for index, part := range req.Multipart {
+		if part.File != "" && !filepath.IsAbs(part.File) {
+			part.File = filepath.Join(profileDir, part.File)
+		}
+		parts[index] = part
+	}
+	return parts, nil
+}
+
+// GetProxy returns the proxy configuration for this NamedRequest
+func (req NamedRequest) GetProxy() proxy.Options {
+	return req.Proxy
+}
+
+// GetRetry returns the retry policy for this NamedRequest
+func (req NamedRequest) GetRetry() retry.Options {
+	return req.Retry
+}
+
+// GetTimeouts returns the timeouts for this NamedRequest
+func (req NamedRequest) GetTimeouts() timeout.Options {
+	return req.Timeouts
+}
+
+// GetTLS returns the TLS options for this NamedRequest with paths relative to the profiles dir resolved
+func (req NamedRequest) GetTLS() (tlsconfig.Options, error) {
+	return resolveTLSPaths(req.TLS)
+}
+
+// GetUnixSocket returns the Unix socket to send this NamedRequest to, relative to the profiles dir
+// if not absolute
+func (req NamedRequest) GetUnixSocket() (string, error) {
+	return resolveUnixSocketPath(req.UnixSocket)
+}
+
 // GetValues returns the values for this NamedRequest
 func (req NamedRequest) GetValues() map[string][]string {
 	return req.Values
 }
+
+// resolveAuthPaths resolves the key file in the authorization if it's relative to the profiles dir
+func resolveAuthPaths(auth authorization.Authorization) (authorization.Authorization, error) {
+	if auth.KeyFile == "" || filepath.IsAbs(auth.KeyFile) {
+		return auth, nil
+	}
+
+	profileDir, profileDirError := GetProfilesDir()
+	if profileDirError != nil {
+		return auth, profileDirError
+	}
+
+	auth.KeyFile = filepath.Join(profileDir, auth.KeyFile)
+	return auth, nil
+}
+
+// resolveTLSPaths resolves paths in the TLS options that are relative to the profiles dir
+func resolveTLSPaths(tlsOptions tlsconfig.Options) (tlsconfig.Options, error) {
+	if !tlsOptions.HasFiles() {
+		return tlsOptions, nil
+	}
+
+	profileDir, profileDirError := GetProfilesDir()
+	if profileDirError != nil {
+		return tlsOptions, profileDirError
+	}
+
+	return tlsOptions.ResolvePaths(profileDir), nil
+}
+
+// resolveUnixSocketPath resolves the path to the Unix socket if it's relative to the profiles dir
+func resolveUnixSocketPath(unixSocket string) (string, error) {
+	if unixSocket == "" || filepath.IsAbs(unixSocket) {
+		return unixSocket, nil
+	}
+
+	profileDir, profileDirError := GetProfilesDir()
+	if profileDirError != nil {
+		return "", profileDirError
+	}
+
+	return filepath.Join(profileDir, unixSocket), nil
+}
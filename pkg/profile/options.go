@@ -1,12 +1,31 @@
 package profile
 
+import (
+	"github.com/visola/go-http-cli/pkg/authorization"
+	"github.com/visola/go-http-cli/pkg/credential"
+	"github.com/visola/go-http-cli/pkg/proxy"
+	"github.com/visola/go-http-cli/pkg/retry"
+	"github.com/visola/go-http-cli/pkg/timeout"
+	"github.com/visola/go-http-cli/pkg/tlsconfig"
+)
+
 // Options that can come from a profile file.
 type Options struct {
-	AllowInsecure bool
-	BaseURL       string
-	Headers       map[string][]string
-	NamedRequest  map[string]NamedRequest
-	Variables     map[string]string
+	AllowInsecure    bool
+	Auth             authorization.Authorization
+	BaseURL          string
+	Headers          map[string][]string
+	HTTPVersion      string // Version of the protocol to use, negotiated if not set
+	NamedRequest     map[string]NamedRequest
+	OnUnauthorized   string // Name of the request to execute when a response is 401
+	Proxy            proxy.Options
+	Retry            retry.Options
+	SecretsLocked    bool // True if a secrets file needs to be decrypted but no passphrase was provided
+	Timeouts         timeout.Options
+	TLS              tlsconfig.Options
+	UnixSocket       string                        // Unix socket to connect to instead of the host in the URL
+	VariableCommands map[string]credential.Command // Variables which values come from commands
+	Variables        map[string]string
 }
 
 // GetAllowInsecure returns if this option allow insecure HTTP connections
@@ -14,45 +33,126 @@ func (ops Options) GetAllowInsecure() bool {
 	return ops.AllowInsecure
 }
 
+// GetAuth returns the authorization set in this option with paths relative to the profiles dir resolved
+func (ops Options) GetAuth() (authorization.Authorization, error) {
+	return resolveAuthPaths(ops.Auth)
+}
+
 // GetHeaders returns the headers set in this option
 func (ops Options) GetHeaders() map[string][]string {
 	return ops.Headers
 }
 
+// GetHTTPVersion returns the version of the protocol set in this option
+func (ops Options) GetHTTPVersion() string {
+	return ops.HTTPVersion
+}
+
+// GetProxy returns the proxy configuration set in this option
+func (ops Options) GetProxy() proxy.Options {
+	return ops.Proxy
+}
+
+// GetRetry returns the retry policy set in this option
+func (ops Options) GetRetry() retry.Options {
+	return ops.Retry
+}
+
+// GetTimeouts returns the timeouts set in this option
+func (ops Options) GetTimeouts() timeout.Options {
+	return ops.Timeouts
+}
+
+// GetTLS returns the TLS options with paths relative to the profiles dir resolved
+func (ops Options) GetTLS() (tlsconfig.Options, error) {
+	return resolveTLSPaths(ops.TLS)
+}
+
+// GetUnixSocket returns the Unix socket set in this option, relative to the profiles dir if not absolute
+func (ops Options) GetUnixSocket() (string, error) {
+	return resolveUnixSocketPath(ops.UnixSocket)
+}
+
 // MergeOptions merges all options passed in into a final Options object.
 func MergeOptions(profiles []Options) Options {
+	auth := authorization.Authorization{}
 	baseURL := ""
 	headers := make(map[string][]string)
+	httpVersion := ""
 	insecure := false
+	onUnauthorized := ""
+	secretsLocked := false
 	requests := make(map[string]NamedRequest)
+	proxyOptions := proxy.Options{}
+	retryOptions := retry.Options{}
+	timeouts := timeout.Options{}
+	tlsOptions := tlsconfig.Options{}
+	unixSocket := ""
+	variableCommands := make(map[string]credential.Command)
 	variables := make(map[string]string)
 
 	// Merge all profiles
 	for _, profile := range profiles {
+		auth = auth.Merge(profile.Auth)
+
 		if profile.BaseURL != "" {
 			baseURL = profile.BaseURL
 		}
 
+		if profile.HTTPVersion != "" {
+			httpVersion = profile.HTTPVersion
+		}
+
 		insecure = insecure || profile.AllowInsecure
+		secretsLocked = secretsLocked || profile.SecretsLocked
+
+		if profile.OnUnauthorized != "" {
+			onUnauthorized = profile.OnUnauthorized
+		}
+
+		proxyOptions = proxyOptions.Merge(profile.Proxy)
+		retryOptions = retryOptions.Merge(profile.Retry)
+		timeouts = timeouts.Merge(profile.Timeouts)
+		tlsOptions = tlsOptions.Merge(profile.TLS)
+
+		if profile.UnixSocket != "" {
+			unixSocket = profile.UnixSocket
+		}
 
 		for header, values := range profile.Headers {
 			headers[header] = append(headers[header], values...)
 		}
 
 		for variable, value := range profile.Variables {
+			delete(variableCommands, variable)
 			variables[variable] = value
 		}
 
+		for variable, command := range profile.VariableCommands {
+			delete(variables, variable)
+			variableCommands[variable] = command
+		}
+
 		for requestName, requestConfiguration := range profile.NamedRequest {
 			requests[requestName] = requestConfiguration
 		}
 	}
 
 	return Options{
-		AllowInsecure: insecure,
-		BaseURL:       baseURL,
-		Headers:       headers,
-		NamedRequest:  requests,
-		Variables:     variables,
+		AllowInsecure:    insecure,
+		Auth:             auth,
+		BaseURL:          baseURL,
+		Headers:          headers,
+		HTTPVersion:      httpVersion,
+		NamedRequest:     requests,
+		OnUnauthorized:   onUnauthorized,
+		Proxy:            proxyOptions,
+		Retry:            retryOptions,
+		SecretsLocked:    secretsLocked,
+		Timeouts:         timeouts,
+		TLS:              tlsOptions,
+		UnixSocket:       unixSocket,
+		VariableCommands: variableCommands,
+		Variables:        variables,
 	}
 }
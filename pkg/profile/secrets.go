@@ -0,0 +1,156 @@
+package profile
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/visola/go-http-cli/pkg/secrets"
+	"gopkg.in/yaml.v2"
+)
+
+const secretsExtension = ".secrets.yml.enc"
+
+// Used to unmarshal secrets from the encrypted secrets files
+type yamlSecretsFormat struct {
+	Auth      authConfiguration `yaml:"auth"`
+	Variables map[string]variableConfiguration
+}
+
+// GetSecretsFile returns the path to the encrypted secrets file for a profile
+func GetSecretsFile(profileName string) (string, error) {
+	profileFile, profileFileErr := findProfileFile(profileName)
+	if profileFileErr != nil {
+		return "", profileFileErr
+	}
+
+	return secretsFileFor(profileFile), nil
+}
+
+// ReadSecrets reads and decrypts the secrets file for a profile. Returns empty if the file doesn't
+// exist yet.
+func ReadSecrets(profileName string, passphrase string) ([]byte, error) {
+	secretsFile, secretsFileErr := GetSecretsFile(profileName)
+	if secretsFileErr != nil {
+		return nil, secretsFileErr
+	}
+
+	encryptedData, readErr := ioutil.ReadFile(secretsFile)
+	if os.IsNotExist(readErr) {
+		return []byte{}, nil
+	}
+
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	return secrets.Decrypt(encryptedData, passphrase)
+}
+
+// WriteSecrets validates, encrypts and writes the secrets file for a profile
+func WriteSecrets(profileName string, passphrase string, plainData []byte) error {
+	if _, parseErr := parseSecrets(plainData); parseErr != nil {
+		return parseErr
+	}
+
+	secretsFile, secretsFileErr := GetSecretsFile(profileName)
+	if secretsFileErr != nil {
+		return secretsFileErr
+	}
+
+	encryptedData, encryptErr := secrets.Encrypt(plainData, passphrase)
+	if encryptErr != nil {
+		return encryptErr
+	}
+
+	return ioutil.WriteFile(secretsFile, encryptedData, 0600)
+}
+
+// SetSecret sets the value for a key in the secrets. Keys are paths separated by dots, e.g.:
+// auth.password. Keys without a dot are set as variables.
+func SetSecret(plainData []byte, key string, value string) ([]byte, error) {
+	var document yaml.MapSlice
+	if unmarshalErr := yaml.Unmarshal(plainData, &document); unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	path := strings.Split(key, ".")
+	if len(path) == 1 {
+		path = []string{"variables", key}
+	}
+
+	return yaml.Marshal(setInMapSlice(document, path, value))
+}
+
+// loadSecrets loads the secrets file that sits next to the profile file, if one exists. Returns
+// true if the file exists but the secrets are still locked.
+func loadSecrets(pathToYamlFile string) (*Options, bool, error) {
+	secretsFile := secretsFileFor(pathToYamlFile)
+	encryptedData, readErr := ioutil.ReadFile(secretsFile)
+	if os.IsNotExist(readErr) {
+		return nil, false, nil
+	}
+
+	if readErr != nil {
+		return nil, false, readErr
+	}
+
+	passphrase, unlocked := secrets.Passphrase()
+	if !unlocked {
+		return nil, true, nil
+	}
+
+	plainData, decryptErr := secrets.Decrypt(encryptedData, passphrase)
+	if decryptErr != nil {
+		return nil, false, decryptErr
+	}
+
+	loadedSecrets, parseErr := parseSecrets(plainData)
+	if parseErr != nil {
+		return nil, false, fmt.Errorf("Error while parsing secrets file %s: %s", secretsFile, parseErr)
+	}
+
+	variables, variableCommands := toVariablesAndCommands(loadedSecrets.Variables)
+	return &Options{
+		Auth:             loadedSecrets.Auth.toAuthorization(),
+		VariableCommands: variableCommands,
+		Variables:        variables,
+	}, false, nil
+}
+
+func parseSecrets(plainData []byte) (*yamlSecretsFormat, error) {
+	loadedSecrets := new(yamlSecretsFormat)
+	if unmarshalErr := yaml.UnmarshalStrict(plainData, loadedSecrets); unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+	return loadedSecrets, nil
+}
+
+func secretsFileFor(pathToYamlFile string) string {
+	return strings.TrimSuffix(pathToYamlFile, filepath.Ext(pathToYamlFile)) + secretsExtension
+}
+
+func setInMapSlice(document yaml.MapSlice, path []string, value string) yaml.MapSlice {
+	for index, item := range document {
+		if item.Key != path[0] {
+			continue
+		}
+
+		if len(path) == 1 {
+			document[index].Value = value
+		} else {
+			child, _ := item.Value.(yaml.MapSlice)
+			document[index].Value = setInMapSlice(child, path[1:], value)
+		}
+
+		return document
+	}
+
+	if len(path) == 1 {
+		return append(document, yaml.MapItem{Key: path[0], Value: value})
+	}
+
+	return append(document, yaml.MapItem{Key: path[0], Value: setInMapSlice(nil, path[1:], value)})
+}
@@ -0,0 +1,64 @@
+package profile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visola/go-http-cli/pkg/secrets"
+)
+
+func TestSecrets(t *testing.T) {
+	t.Run("Merges secrets when unlocked", testMergesSecretsWhenUnlocked)
+	t.Run("Marks profile when secrets are locked", testMarksProfileWhenSecretsLocked)
+	t.Run("Sets values in secrets", testSetsValuesInSecrets)
+	t.Run("Does not write invalid secrets", testDoesNotWriteInvalidSecrets)
+}
+
+func testMergesSecretsWhenUnlocked(t *testing.T) {
+	profilesDir := SetupTestProfilesDir()
+	CreateTestProfile("secure", "auth:\n  type: basic\n  username: someone\nvariables:\n  plain: value\n", profilesDir)
+	require.Nil(t, WriteSecrets("secure", "my passphrase", []byte("auth:\n  password: secret\nvariables:\n  apiKey: some-key\n")))
+
+	secrets.Unlock("my passphrase")
+	defer secrets.Lock()
+
+	loadedProfile, loadErr := LoadProfile("secure")
+	require.Nil(t, loadErr, "Should load profile")
+	assert.False(t, loadedProfile.SecretsLocked, "Should not be locked")
+	assert.Equal(t, "someone", loadedProfile.Auth.Username, "Should keep username from profile")
+	assert.Equal(t, "secret", loadedProfile.Auth.Password, "Should merge password from secrets")
+	assert.Equal(t, map[string]string{"apiKey": "some-key", "plain": "value"}, loadedProfile.Variables, "Should merge variables from secrets")
+
+	_, hasAuthHeader := loadedProfile.Headers["Authorization"]
+	assert.False(t, hasAuthHeader, "Should set authorization when executing the request")
+}
+
+func testMarksProfileWhenSecretsLocked(t *testing.T) {
+	profilesDir := SetupTestProfilesDir()
+	CreateTestProfile("secure", "auth:\n  type: basic\n  username: someone\n", profilesDir)
+	require.Nil(t, WriteSecrets("secure", "my passphrase", []byte("auth:\n  password: secret\n")))
+
+	loadedProfile, loadErr := LoadProfile("secure")
+	require.Nil(t, loadErr, "Should load profile without secrets")
+	assert.True(t, loadedProfile.SecretsLocked, "Should be locked")
+	assert.Equal(t, "", loadedProfile.Auth.Password, "Should not have secrets")
+}
+
+func testSetsValuesInSecrets(t *testing.T) {
+	plainData, err := SetSecret([]byte("auth:\n  password: old\nvariables:\n  other: value\n"), "auth.password", "new")
+	require.Nil(t, err)
+
+	plainData, err = SetSecret(plainData, "apiKey", "some-key")
+	require.Nil(t, err)
+
+	assert.Equal(t, "auth:\n  password: new\nvariables:\n  other: value\n  apiKey: some-key\n", string(plainData))
+}
+
+func testDoesNotWriteInvalidSecrets(t *testing.T) {
+	profilesDir := SetupTestProfilesDir()
+	CreateTestProfile("secure", "baseURL: http://localhost\n", profilesDir)
+
+	err := WriteSecrets("secure", "my passphrase", []byte("unknown: field\n"))
+	assert.NotNil(t, err, "Should not write unknown fields")
+}
@@ -0,0 +1,82 @@
+package profile
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/visola/go-http-cli/pkg/credential"
+)
+
+// Used to unmarshal commands that can be configured as a string or with options from yaml files
+type commandConfiguration struct {
+	Command string
+	TTL     time.Duration
+}
+
+// Used to unmarshal variables that can be a value or come from a command from yaml files
+type variableConfiguration struct {
+	Command commandConfiguration
+	Value   string
+}
+
+// UnmarshalYAML implement the unmarshal from YAML package
+func (loaded *commandConfiguration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	loaded.TTL = credential.DefaultTTL
+
+	var command string
+	if err := unmarshal(&command); err == nil {
+		loaded.Command = command
+		return nil
+	}
+
+	var withOptions struct {
+		Command string
+		TTL     string `yaml:"ttl"`
+	}
+
+	if err := unmarshal(&withOptions); err != nil {
+		return err
+	}
+
+	loaded.Command = withOptions.Command
+	if withOptions.TTL != "" {
+		ttl, parseErr := time.ParseDuration(withOptions.TTL)
+		if parseErr != nil {
+			return fmt.Errorf("Invalid TTL '%s' for command '%s': %s", withOptions.TTL, withOptions.Command, parseErr)
+		}
+		loaded.TTL = ttl
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implement the unmarshal from YAML package
+func (loaded *variableConfiguration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		loaded.Value = value
+		return nil
+	}
+
+	return unmarshal(&loaded.Command)
+}
+
+func (loaded commandConfiguration) toCommand() credential.Command {
+	return credential.Command{
+		Command: loaded.Command,
+		TTL:     loaded.TTL,
+	}
+}
+
+func toVariablesAndCommands(loadedVariables map[string]variableConfiguration) (map[string]string, map[string]credential.Command) {
+	variables := make(map[string]string)
+	commands := make(map[string]credential.Command)
+	for name, loadedVariable := range loadedVariables {
+		if loadedVariable.Command.Command != "" {
+			commands[name] = loadedVariable.Command.toCommand()
+		} else {
+			variables[name] = loadedVariable.Value
+		}
+	}
+	return variables, commands
+}
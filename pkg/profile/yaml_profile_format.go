@@ -1,78 +1,194 @@
 package profile
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"github.com/visola/go-http-cli/pkg/authorization"
+	"github.com/visola/go-http-cli/pkg/formdata"
 	"github.com/visola/go-http-cli/pkg/model"
+	"github.com/visola/go-http-cli/pkg/proxy"
+	"github.com/visola/go-http-cli/pkg/retry"
+	"github.com/visola/go-http-cli/pkg/timeout"
+	"github.com/visola/go-http-cli/pkg/tlsconfig"
 )
 
 // Used to unmarshal data from YAML files
 type yamlProfileFormat struct {
-	Auth      authConfiguration `yaml:"auth"`
-	BaseURL   string            `yaml:"baseURL"`
-	Headers   map[string]model.ArrayOrString
-	Insecure  bool
-	Import    model.ArrayOrString `yaml:"import"`
-	Requests  map[string]requestConfiguration
-	Variables map[string]string
+	Auth           authConfiguration `yaml:"auth"`
+	BaseURL        string            `yaml:"baseURL"`
+	Headers        map[string]model.ArrayOrString
+	HTTPVersion    string `yaml:"httpVersion"`
+	Insecure       bool
+	Import         model.ArrayOrString `yaml:"import"`
+	OnUnauthorized string              `yaml:"onUnauthorized"`
+	Proxy          proxyConfiguration  `yaml:"proxy"`
+	Requests       map[string]requestConfiguration
+	Retry          retryConfiguration    `yaml:"retry"`
+	Timeouts       timeoutsConfiguration `yaml:"timeouts"`
+	TLS            tlsConfiguration      `yaml:"tls"`
+	UnixSocket     string                `yaml:"unixSocket"`
+	Variables      map[string]variableConfiguration
 }
 
 // Used to unmarshal auth options from yaml files
 type authConfiguration struct {
-	AuthType string `yaml:"type"`
-	Password string
-	Token    string
-	Username string
+	AccessKeyID            string `yaml:"accessKeyId"`
+	Algorithm              string
+	AuthType               string `yaml:"type"`
+	AuthorizationURL       string `yaml:"authorizationURL"`
+	CanonicalString        string `yaml:"canonicalString"`
+	Claims                 map[string]string
+	ClientID               string `yaml:"clientId"`
+	ClientSecret           string `yaml:"clientSecret"`
+	ConsumerKey            string `yaml:"consumerKey"`
+	ConsumerSecret         string `yaml:"consumerSecret"`
+	DeviceAuthorizationURL string `yaml:"deviceAuthorizationURL"`
+	Encoding               string
+	Header                 string
+	HeaderValue            string `yaml:"headerValue"`
+	KeyFile                string `yaml:"keyFile"`
+	KeyID                  string `yaml:"keyId"`
+	Password               string
+	PasswordCommand        commandConfiguration `yaml:"passwordCommand"`
+	PrivateKey             string               `yaml:"privateKey"`
+	Realm                  string
+	RedirectURL            string `yaml:"redirectURL"`
+	Region                 string
+	Scopes                 model.ArrayOrString
+	Secret                 string
+	SecretAccessKey        string `yaml:"secretAccessKey"`
+	Service                string
+	SessionToken           string `yaml:"sessionToken"`
+	SignatureMethod        string `yaml:"signatureMethod"`
+	TimestampHeader        string `yaml:"timestampHeader"`
+	Token                  string
+	TokenCommand           commandConfiguration `yaml:"tokenCommand"`
+	TokenSecret            string               `yaml:"tokenSecret"`
+	TokenURL               string               `yaml:"tokenURL"`
+	Username               string
 }
 
 // Used to unmarshal request options from yaml files
 type requestConfiguration struct {
+	Auth              authConfiguration `yaml:"auth"`
 	Body              string
 	FileToUpload      string `yaml:"fileToUpload"`
 	Headers           map[string]model.ArrayOrString
+	HTTPVersion       string `yaml:"httpVersion"`
 	Insecure          bool
 	Method            string
-	PostProcessScript string `yaml:"postProcessScript"`
+	Multipart         []partConfiguration
+	PostProcessScript string                `yaml:"postProcessScript"`
+	Proxy             proxyConfiguration    `yaml:"proxy"`
+	Retry             retryConfiguration    `yaml:"retry"`
+	Timeouts          timeoutsConfiguration `yaml:"timeouts"`
+	TLS               tlsConfiguration      `yaml:"tls"`
+	UnixSocket        string                `yaml:"unixSocket"`
 	URL               string
 	Values            map[string]model.ArrayOrString
 }
 
-func (loadedProfile yamlProfileFormat) toOptions() (*Options, error) {
-	headers, headersError := generateHeaders(loadedProfile)
+// Used to unmarshal parts of multipart forms from yaml files, which can be like curl's -F or have options
+type partConfiguration struct {
+	Part formdata.Part
+}
 
-	if headersError != nil {
-		return nil, headersError
-	}
+// Used to unmarshal the proxy from yaml files, which can be a URL or have options
+type proxyConfiguration struct {
+	Options proxy.Options
+}
 
-	return &Options{
-		AllowInsecure: loadedProfile.Insecure,
-		BaseURL:       loadedProfile.BaseURL,
-		Headers:       headers,
-		NamedRequest:  toMapOfNamedRequest(loadedProfile.Requests),
-		Variables:     loadedProfile.Variables,
-	}, nil
+// Used to unmarshal the retry policy from yaml files
+type retryConfiguration struct {
+	Options retry.Options
 }
 
-func generateHeaders(loadedProfile yamlProfileFormat) (map[string][]string, error) {
-	result := model.ToMapOfArrayOfStrings(loadedProfile.Headers)
+// Used to unmarshal timeouts from yaml files
+type timeoutsConfiguration struct {
+	Options timeout.Options
+}
 
-	if loadedProfile.Auth.AuthType != "" {
-		loadedAuth := loadedProfile.Auth
-		auth := authorization.Authorization{
-			AuthorizationType: loadedAuth.AuthType,
-			Password:          loadedAuth.Password,
-			Username:          loadedAuth.Username,
-			Token:             loadedAuth.Token,
-		}
+// Used to unmarshal TLS options from yaml files
+type tlsConfiguration struct {
+	CAFile           string `yaml:"caFile"`
+	Cert             string
+	Key              string
+	MaxVersion       string `yaml:"maxVersion"`
+	MinVersion       string `yaml:"minVersion"`
+	Password         string
+	PinnedPublicKeys model.ArrayOrString `yaml:"pinnedPublicKeys"`
+	PKCS12           string              `yaml:"pkcs12"`
+}
 
-		authValue, authError := auth.ToHeaderValue()
-		if authError != nil {
-			return nil, authError
-		}
+func (loadedProfile yamlProfileFormat) toOptions(hasSecrets bool) (*Options, error) {
+	auth := loadedProfile.Auth.toAuthorization()
 
-		result[auth.ToHeaderKey()] = []string{authValue}
+	// Secrets can complete the authorization, so it can only be validated with them
+	if auth.AuthorizationType != "" && !hasSecrets {
+		if validationErr := auth.IsValid(); validationErr != nil {
+			return nil, validationErr
+		}
 	}
 
-	return result, nil
+	variables, variableCommands := toVariablesAndCommands(loadedProfile.Variables)
+
+	return &Options{
+		AllowInsecure:    loadedProfile.Insecure,
+		Auth:             auth,
+		BaseURL:          loadedProfile.BaseURL,
+		Headers:          model.ToMapOfArrayOfStrings(loadedProfile.Headers),
+		HTTPVersion:      loadedProfile.HTTPVersion,
+		NamedRequest:     toMapOfNamedRequest(loadedProfile.Requests),
+		OnUnauthorized:   loadedProfile.OnUnauthorized,
+		Proxy:            loadedProfile.Proxy.Options,
+		Retry:            loadedProfile.Retry.Options,
+		Timeouts:         loadedProfile.Timeouts.Options,
+		TLS:              loadedProfile.TLS.toOptions(),
+		UnixSocket:       loadedProfile.UnixSocket,
+		VariableCommands: variableCommands,
+		Variables:        variables,
+	}, nil
+}
+
+func (loadedAuth authConfiguration) toAuthorization() authorization.Authorization {
+	return authorization.Authorization{
+		AccessKeyID:            loadedAuth.AccessKeyID,
+		Algorithm:              loadedAuth.Algorithm,
+		AuthorizationType:      loadedAuth.AuthType,
+		AuthorizationURL:       loadedAuth.AuthorizationURL,
+		CanonicalString:        loadedAuth.CanonicalString,
+		Claims:                 loadedAuth.Claims,
+		ClientID:               loadedAuth.ClientID,
+		ClientSecret:           loadedAuth.ClientSecret,
+		ConsumerKey:            loadedAuth.ConsumerKey,
+		ConsumerSecret:         loadedAuth.ConsumerSecret,
+		DeviceAuthorizationURL: loadedAuth.DeviceAuthorizationURL,
+		Encoding:               loadedAuth.Encoding,
+		Header:                 loadedAuth.Header,
+		HeaderValue:            loadedAuth.HeaderValue,
+		KeyFile:                loadedAuth.KeyFile,
+		KeyID:                  loadedAuth.KeyID,
+		Password:               loadedAuth.Password,
+		PasswordCommand:        loadedAuth.PasswordCommand.toCommand(),
+		PrivateKey:             loadedAuth.PrivateKey,
+		Realm:                  loadedAuth.Realm,
+		RedirectURL:            loadedAuth.RedirectURL,
+		Region:                 loadedAuth.Region,
+		Scopes:                 loadedAuth.Scopes,
+		Secret:                 loadedAuth.Secret,
+		SecretAccessKey:        loadedAuth.SecretAccessKey,
+		Service:                loadedAuth.Service,
+		SessionToken:           loadedAuth.SessionToken,
+		SignatureMethod:        loadedAuth.SignatureMethod,
+		TimestampHeader:        loadedAuth.TimestampHeader,
+		Token:                  loadedAuth.Token,
+		TokenCommand:           loadedAuth.TokenCommand.toCommand(),
+		TokenSecret:            loadedAuth.TokenSecret,
+		TokenURL:               loadedAuth.TokenURL,
+		Username:               loadedAuth.Username,
+	}
 }
 
 func toMapOfNamedRequest(requestConfigurations map[string]requestConfiguration) map[string]NamedRequest {
@@ -80,11 +196,19 @@ func toMapOfNamedRequest(requestConfigurations map[string]requestConfiguration)
 	for name, requestConfiguration := range requestConfigurations {
 		result[name] = NamedRequest{
 			AllowInsecure:     requestConfiguration.Insecure,
+			Auth:              requestConfiguration.Auth.toAuthorization(),
 			Body:              requestConfiguration.Body,
 			FileToUpload:      requestConfiguration.FileToUpload,
 			Headers:           model.ToMapOfArrayOfStrings(requestConfiguration.Headers),
+			HTTPVersion:       requestConfiguration.HTTPVersion,
 			Method:            requestConfiguration.Method,
+			Multipart:         toParts(requestConfiguration.Multipart),
 			PostProcessScript: requestConfiguration.PostProcessScript,
+			Proxy:             requestConfiguration.Proxy.Options,
+			Retry:             requestConfiguration.Retry.Options,
+			Timeouts:          requestConfiguration.Timeouts.Options,
+			TLS:               requestConfiguration.TLS.toOptions(),
+			UnixSocket:        requestConfiguration.UnixSocket,
 			URL:               requestConfiguration.URL,
 			Values:            model.ToMapOfArrayOfStrings(requestConfiguration.Values),
 		}
@@ -92,3 +216,168 @@ func toMapOfNamedRequest(requestConfigurations map[string]requestConfiguration)
 
 	return result
 }
+
+func toParts(partConfigurations []partConfiguration) []formdata.Part {
+	if len(partConfigurations) == 0 {
+		return nil
+	}
+
+	result := make([]formdata.Part, len(partConfigurations))
+	for index, partConfiguration := range partConfigurations {
+		result[index] = partConfiguration.Part
+	}
+	return result
+}
+
+func (loadedTLS tlsConfiguration) toOptions() tlsconfig.Options {
+	return tlsconfig.Options{
+		CAFile:           loadedTLS.CAFile,
+		Cert:             loadedTLS.Cert,
+		Key:              loadedTLS.Key,
+		MaxVersion:       loadedTLS.MaxVersion,
+		MinVersion:       loadedTLS.MinVersion,
+		Password:         loadedTLS.Password,
+		PinnedPublicKeys: loadedTLS.PinnedPublicKeys,
+		PKCS12:           loadedTLS.PKCS12,
+	}
+}
+
+// UnmarshalYAML implement the unmarshal from YAML package
+func (loaded *timeoutsConfiguration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var timeouts struct {
+		Connect        string
+		Request        string
+		ResponseHeader string `yaml:"responseHeader"`
+		TLS            string `yaml:"tls"`
+	}
+
+	if err := unmarshal(&timeouts); err != nil {
+		return err
+	}
+
+	toParse := []struct {
+		name   string
+		value  string
+		target *time.Duration
+	}{
+		{"connect", timeouts.Connect, &loaded.Options.Connect},
+		{"request", timeouts.Request, &loaded.Options.Request},
+		{"responseHeader", timeouts.ResponseHeader, &loaded.Options.ResponseHeader},
+		{"tls", timeouts.TLS, &loaded.Options.TLSHandshake},
+	}
+
+	for _, duration := range toParse {
+		parsed, parseErr := timeout.ParseDuration(duration.value)
+		if parseErr != nil {
+			return fmt.Errorf("Invalid %s timeout: %s", duration.name, parseErr)
+		}
+		*duration.target = parsed
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implement the unmarshal from YAML package
+func (loaded *partConfiguration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var field string
+	if err := unmarshal(&field); err == nil {
+		part, parseErr := formdata.ParsePart(field)
+		loaded.Part = part
+		return parseErr
+	}
+
+	var partOptions struct {
+		File     string
+		FileName string `yaml:"fileName"`
+		Name     string
+		Type     string
+		Value    string
+	}
+
+	if err := unmarshal(&partOptions); err != nil {
+		return err
+	}
+
+	if partOptions.Name == "" {
+		return fmt.Errorf("Multipart field without a name: %+v", partOptions)
+	}
+
+	loaded.Part = formdata.Part{
+		ContentType: partOptions.Type,
+		File:        partOptions.File,
+		FileName:    partOptions.FileName,
+		Name:        partOptions.Name,
+		Value:       partOptions.Value,
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implement the unmarshal from YAML package
+func (loaded *proxyConfiguration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var proxyURL string
+	if err := unmarshal(&proxyURL); err == nil {
+		loaded.Options.URL = proxyURL
+		return nil
+	}
+
+	var proxyOptions struct {
+		NoProxy  model.ArrayOrString `yaml:"noProxy"`
+		Password string
+		URL      string
+		Username string
+	}
+
+	if err := unmarshal(&proxyOptions); err != nil {
+		return err
+	}
+
+	loaded.Options = proxy.Options{
+		NoProxy:  proxy.SplitNoProxy(strings.Join(proxyOptions.NoProxy, ",")),
+		Password: proxyOptions.Password,
+		URL:      proxyOptions.URL,
+		Username: proxyOptions.Username,
+	}
+
+	return nil
+}
+
+// UnmarshalYAML implement the unmarshal from YAML package
+func (loaded *retryConfiguration) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var retryOptions struct {
+		Attempts      int
+		Delay         string
+		MaxDelay      string `yaml:"maxDelay"`
+		NonIdempotent bool   `yaml:"nonIdempotent"`
+		On            model.ArrayOrString
+	}
+
+	if err := unmarshal(&retryOptions); err != nil {
+		return err
+	}
+
+	delay, delayErr := timeout.ParseDuration(retryOptions.Delay)
+	if delayErr != nil {
+		return fmt.Errorf("Invalid retry delay: %s", delayErr)
+	}
+
+	maxDelay, maxDelayErr := timeout.ParseDuration(retryOptions.MaxDelay)
+	if maxDelayErr != nil {
+		return fmt.Errorf("Invalid retry max delay: %s", maxDelayErr)
+	}
+
+	conditions, conditionsErr := retry.ParseConditions(strings.Join(retryOptions.On, ","))
+	if conditionsErr != nil {
+		return conditionsErr
+	}
+
+	loaded.Options = retry.Options{
+		Attempts:      retryOptions.Attempts,
+		Delay:         delay,
+		MaxDelay:      maxDelay,
+		NonIdempotent: retryOptions.NonIdempotent,
+		On:            conditions,
+	}
+
+	return nil
+}
@@ -0,0 +1,55 @@
+package progress
+
+import "time"
+
+// How often progress is reported while transferring
+const reportInterval = 100 * time.Millisecond
+
+// Progress reports how much of a body was transferred to or from a file
+type Progress struct {
+	Done        bool
+	File        string
+	Total       int64 // Size of the body, -1 if unknown
+	Transferred int64
+	Upload      bool // True if the body is being sent, false if it's being received
+}
+
+// Reporter counts what's transferred and reports the progress periodically
+type Reporter struct {
+	lastReport time.Time
+	onProgress func(Progress)
+	progress   Progress
+}
+
+// NewReporter creates a reporter that calls onProgress, which can be nil, with the progress
+func NewReporter(initial Progress, onProgress func(Progress)) *Reporter {
+	return &Reporter{
+		onProgress: onProgress,
+		progress:   initial,
+	}
+}
+
+// Done reports that the transfer finished
+func (reporter *Reporter) Done() {
+	if reporter.progress.Done {
+		return
+	}
+	reporter.progress.Done = true
+	reporter.report()
+}
+
+// Write counts the data transferred, it never fails
+func (reporter *Reporter) Write(data []byte) (int, error) {
+	reporter.progress.Transferred += int64(len(data))
+	if time.Since(reporter.lastReport) >= reportInterval {
+		reporter.report()
+	}
+	return len(data), nil
+}
+
+func (reporter *Reporter) report() {
+	reporter.lastReport = time.Now()
+	if reporter.onProgress != nil {
+		reporter.onProgress(reporter.progress)
+	}
+}
@@ -0,0 +1,134 @@
+package protocol
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// http3RoundTripper sends requests with HTTP/3, waiting for the response headers only as long as the
+// transport for the other versions would. It has to be closed to release its UDP sockets.
+type http3RoundTripper struct {
+	*http3.Transport
+	responseHeaderTimeout time.Duration
+}
+
+func newHTTP3RoundTripper(transport *http.Transport, connectTimeout time.Duration) http3RoundTripper {
+	return http3RoundTripper{
+		Transport: &http3.Transport{
+			Dial:            dialQUIC(connectTimeout),
+			QUICConfig:      &quic.Config{HandshakeIdleTimeout: transport.TLSHandshakeTimeout},
+			TLSClientConfig: transport.TLSClientConfig,
+		},
+		responseHeaderTimeout: transport.ResponseHeaderTimeout,
+	}
+}
+
+func (transport http3RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if transport.responseHeaderTimeout <= 0 {
+		return transport.Transport.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithCancel(req.Context())
+	timer := &headerTimer{cancel: cancel, timeout: transport.responseHeaderTimeout}
+	ctx = httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		WroteRequest: func(httptrace.WroteRequestInfo) { timer.start() },
+	})
+
+	response, err := transport.Transport.RoundTrip(req.WithContext(ctx))
+	if timer.stop() {
+		if response != nil {
+			response.Body.Close()
+		}
+		cancel()
+		return nil, errors.New("http3: timeout awaiting response headers")
+	}
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	response.Body = cancelOnClose{ReadCloser: response.Body, cancel: cancel}
+	return response, nil
+}
+
+// dialQUIC creates a QUIC connection with its own UDP socket, that is closed with the connection.
+// QUIC connects during the TLS handshake, so the connect timeout includes it.
+func dialQUIC(connectTimeout time.Duration) func(context.Context, string, *tls.Config, *quic.Config) (*quic.Conn, error) {
+	return func(ctx context.Context, addr string, tlsConfig *tls.Config, quicConfig *quic.Config) (*quic.Conn, error) {
+		if connectTimeout <= 0 {
+			return quic.DialAddrEarly(ctx, addr, tlsConfig, quicConfig)
+		}
+
+		connectCtx, cancel := context.WithTimeout(ctx, connectTimeout)
+		defer cancel()
+
+		conn, dialErr := quic.DialAddrEarly(connectCtx, addr, tlsConfig, quicConfig)
+		if dialErr != nil && ctx.Err() == nil && connectCtx.Err() == context.DeadlineExceeded {
+			return nil, &net.OpError{Op: "dial", Net: "udp", Err: connectCtx.Err()}
+		}
+		return conn, dialErr
+	}
+}
+
+// headerTimer cancels a request if the response headers don't arrive in time after it was sent
+type headerTimer struct {
+	cancel   context.CancelFunc
+	mutex    sync.Mutex
+	stopped  bool
+	timedOut bool
+	timeout  time.Duration
+	timer    *time.Timer
+}
+
+func (timer *headerTimer) start() {
+	timer.mutex.Lock()
+	defer timer.mutex.Unlock()
+
+	if timer.stopped || timer.timer != nil {
+		return
+	}
+
+	timer.timer = time.AfterFunc(timer.timeout, func() {
+		timer.mutex.Lock()
+		defer timer.mutex.Unlock()
+
+		if !timer.stopped {
+			timer.timedOut = true
+			timer.cancel()
+		}
+	})
+}
+
+// stop stops waiting for the response headers and returns true if they took too long
+func (timer *headerTimer) stop() bool {
+	timer.mutex.Lock()
+	defer timer.mutex.Unlock()
+
+	timer.stopped = true
+	if timer.timer != nil {
+		timer.timer.Stop()
+	}
+	return timer.timedOut
+}
+
+// cancelOnClose releases the context of a request when its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body cancelOnClose) Close() error {
+	defer body.cancel()
+	return body.ReadCloser.Close()
+}
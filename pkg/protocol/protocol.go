@@ -0,0 +1,105 @@
+package protocol
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// HTTP1 only sends requests with HTTP/1.1
+	HTTP1 = "1.1"
+
+	// HTTP2 only sends requests with HTTP/2 negotiated during the TLS handshake
+	HTTP2 = "2"
+
+	// HTTP2PriorKnowledge sends requests with HTTP/2 without TLS (h2c), knowing that the server
+	// supports it instead of asking for an upgrade
+	HTTP2PriorKnowledge = "2-prior-knowledge"
+
+	// HTTP3 sends requests with HTTP/3 over QUIC
+	HTTP3 = "3"
+)
+
+// Error is returned when a request can't be sent with the version of the protocol asked for
+type Error struct {
+	Err     error
+	Version string
+}
+
+func (err Error) Error() string {
+	return fmt.Sprintf("Can't use HTTP/%s: %s", strings.TrimSuffix(err.Version, "-prior-knowledge"), err.Err)
+}
+
+// Validate checks that the version is supported, empty means the version is negotiated
+func Validate(version string) error {
+	switch version {
+	case "", HTTP1, HTTP2, HTTP2PriorKnowledge, HTTP3:
+		return nil
+	}
+	return fmt.Errorf("Unsupported HTTP version '%s', must be one of: %s, %s, %s, %s", version, HTTP1, HTTP2, HTTP2PriorKnowledge, HTTP3)
+}
+
+// RoundTripper creates what sends requests with the version of the protocol, configuring the
+// transport for the versions that run over TCP, where the dialer has the connect timeout. When the
+// version is not set, HTTP/2 is used if negotiated during the TLS handshake, falling back to
+// HTTP/1.1. HTTP/3 uses the TLS configuration and the timeouts from the transport and the connect
+// timeout, its round tripper is an io.Closer that must be closed after use.
+func RoundTripper(version string, transport *http.Transport, connectTimeout time.Duration) (http.RoundTripper, error) {
+	if validationErr := Validate(version); validationErr != nil {
+		return nil, validationErr
+	}
+
+	if version == HTTP3 {
+		return newHTTP3RoundTripper(transport, connectTimeout), nil
+	}
+
+	protocols := new(http.Protocols)
+	switch version {
+	case HTTP1:
+		protocols.SetHTTP1(true)
+	case HTTP2:
+		protocols.SetHTTP2(true)
+	case HTTP2PriorKnowledge:
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	}
+
+	// Without this, setting the TLS configuration or the dialer turns HTTP/2 off
+	transport.ForceAttemptHTTP2 = true
+	transport.Protocols = protocols
+
+	if version == HTTP2 {
+		return requireTLS{transport}, nil
+	}
+	return transport, nil
+}
+
+// DescribeError wraps errors caused by the server not supporting the version of the protocol in an
+// Error. Other errors are returned as they are.
+func DescribeError(version string, err error) error {
+	if err == nil || version == "" || version == HTTP1 {
+		return err
+	}
+
+	if strings.Contains(err.Error(), "no application protocol") {
+		return Error{Err: fmt.Errorf("server doesn't support it: %s", err), Version: version}
+	}
+	return err
+}
+
+// requireTLS fails requests without TLS, where HTTP/2 can't be negotiated and Go would quietly
+// fall back to HTTP/1.1
+type requireTLS struct {
+	*http.Transport
+}
+
+func (transport requireTLS) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" {
+		return nil, Error{Err: fmt.Errorf("%s has no TLS to negotiate it, use prior knowledge to send it without TLS", req.URL), Version: HTTP2}
+	}
+	return transport.Transport.RoundTrip(req)
+}
@@ -0,0 +1,169 @@
+package protocol
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go/http3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visola/go-http-cli/pkg/tlsconfig"
+)
+
+func TestRoundTripper(t *testing.T) {
+	t.Run("Negotiates HTTP/2 when not set", testNegotiatesHTTP2WhenNotSet)
+	t.Run("Uses HTTP/1.1 when asked to", testUsesHTTP1WhenAskedTo)
+	t.Run("Fails with HTTP/2 if it can't be negotiated", testFailsWithHTTP2IfItCannotBeNegotiated)
+	t.Run("Uses HTTP/2 without TLS with prior knowledge", testUsesHTTP2WithPriorKnowledge)
+	t.Run("Uses HTTP/3", testUsesHTTP3)
+	t.Run("Limits HTTP/3 connect and response header timeouts", testLimitsHTTP3Timeouts)
+	t.Run("Fails with unsupported version", testFailsWithUnsupportedVersion)
+}
+
+func testNegotiatesHTTP2WhenNotSet(t *testing.T) {
+	server := httptest.NewUnstartedServer(protoHandler())
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	assert.Equal(t, "HTTP/2.0", get(t, "", server.URL), "Should negotiate HTTP/2")
+}
+
+func testUsesHTTP1WhenAskedTo(t *testing.T) {
+	server := httptest.NewUnstartedServer(protoHandler())
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	assert.Equal(t, "HTTP/1.1", get(t, HTTP1, server.URL), "Should use HTTP/1.1")
+}
+
+func testFailsWithHTTP2IfItCannotBeNegotiated(t *testing.T) {
+	server := httptest.NewTLSServer(protoHandler())
+	defer server.Close()
+
+	roundTripper, err := RoundTripper(HTTP2, insecureTransport(), 0)
+	require.Nil(t, err, "Should create round tripper")
+
+	_, getErr := (&http.Client{Transport: roundTripper}).Get(server.URL)
+	require.NotNil(t, getErr, "Should fail if HTTP/2 is not negotiated")
+	assert.IsType(t, Error{}, DescribeError(HTTP2, getErr), "Should describe error")
+
+	plainServer := httptest.NewServer(protoHandler())
+	defer plainServer.Close()
+
+	_, plainErr := (&http.Client{Transport: roundTripper}).Get(plainServer.URL)
+	assert.NotNil(t, plainErr, "Should fail without TLS instead of falling back to HTTP/1.1")
+}
+
+func testUsesHTTP2WithPriorKnowledge(t *testing.T) {
+	server := httptest.NewUnstartedServer(protoHandler())
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	defer server.Close()
+
+	assert.Equal(t, "HTTP/1.1", get(t, "", server.URL), "Should use HTTP/1.1 without TLS when not set")
+	assert.Equal(t, "HTTP/2.0", get(t, HTTP2PriorKnowledge, server.URL), "Should use HTTP/2 without TLS")
+}
+
+func testUsesHTTP3(t *testing.T) {
+	address, closeServer := startHTTP3Server(t, protoHandler())
+	defer closeServer()
+
+	assert.Equal(t, "HTTP/3.0", get(t, HTTP3, "https://"+address), "Should use HTTP/3")
+}
+
+func testLimitsHTTP3Timeouts(t *testing.T) {
+	// Nothing answers the QUIC handshake
+	conn, listenErr := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, listenErr, "Should listen")
+	defer conn.Close()
+
+	roundTripper, err := RoundTripper(HTTP3, insecureTransport(), 100*time.Millisecond)
+	require.Nil(t, err, "Should create round tripper")
+	defer roundTripper.(io.Closer).Close()
+
+	_, getErr := (&http.Client{Transport: roundTripper}).Get("https://" + conn.LocalAddr().String())
+	var opErr *net.OpError
+	require.True(t, errors.As(getErr, &opErr), "Should fail to connect: %s", getErr)
+	assert.True(t, opErr.Op == "dial" && opErr.Timeout(), "Should time out connecting")
+
+	address, closeServer := startHTTP3Server(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Second)
+	}))
+	defer closeServer()
+
+	transport := insecureTransport()
+	transport.ResponseHeaderTimeout = 100 * time.Millisecond
+	roundTripper, err = RoundTripper(HTTP3, transport, 0)
+	require.Nil(t, err, "Should create round tripper")
+	defer roundTripper.(io.Closer).Close()
+
+	_, getErr = (&http.Client{Transport: roundTripper}).Get("https://" + address)
+	require.NotNil(t, getErr, "Should not wait for slow response headers")
+	assert.Contains(t, getErr.Error(), "timeout awaiting response headers", "Should say what timed out")
+}
+
+func testFailsWithUnsupportedVersion(t *testing.T) {
+	_, err := RoundTripper("4", insecureTransport(), 0)
+	assert.NotNil(t, err, "Should fail with unsupported version")
+}
+
+func get(t *testing.T, version string, url string) string {
+	roundTripper, err := RoundTripper(version, insecureTransport(), 0)
+	require.Nil(t, err, "Should create round tripper")
+	if closer, isCloser := roundTripper.(io.Closer); isCloser {
+		defer closer.Close()
+	}
+
+	response, getErr := (&http.Client{Transport: roundTripper}).Get(url)
+	require.Nil(t, getErr, "Should execute request")
+	defer response.Body.Close()
+
+	body, readErr := ioutil.ReadAll(response.Body)
+	require.Nil(t, readErr, "Should read body")
+	assert.Equal(t, response.Proto, string(body), "Should use the same protocol on both sides")
+	return response.Proto
+}
+
+func startHTTP3Server(t *testing.T, handler http.Handler) (string, func()) {
+	dir, dirErr := ioutil.TempDir("", "protocol")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := tlsconfig.CreateTestCertificate(dir, "server")
+	certificate, certErr := tls.LoadX509KeyPair(certFile, keyFile)
+	require.Nil(t, certErr, "Should load certificate")
+
+	conn, listenErr := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, listenErr, "Should listen")
+
+	server := &http3.Server{
+		Handler:   handler,
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: []tls.Certificate{certificate}}),
+	}
+	go server.Serve(conn)
+
+	return conn.LocalAddr().String(), func() { server.Close() }
+}
+
+func insecureTransport() *http.Transport {
+	return &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+}
+
+func protoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	})
+}
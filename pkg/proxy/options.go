@@ -0,0 +1,181 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Options configures the proxy used to send requests
+type Options struct {
+	NoProxy  []string // Hosts, domains and networks that are accessed without the proxy, * for all
+	Password string
+	URL      string // Proxy to use for all requests, e.g.: http://proxy:3128 or socks5://proxy:1080
+	Username string
+}
+
+// Environment is the proxy configuration from the environment variables where the request was made
+type Environment struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+}
+
+// FromEnvironment reads the proxy configuration from the environment variables, accepting upper
+// and lower case names like curl does. Like curl, only the lower case http_proxy is used because
+// the upper case one can be set by CGI servers from the Proxy request header.
+func FromEnvironment() Environment {
+	allProxy := getEnv("ALL_PROXY")
+	return Environment{
+		HTTPProxy:  coalesce(os.Getenv("http_proxy"), allProxy),
+		HTTPSProxy: coalesce(getEnv("HTTPS_PROXY"), allProxy),
+		NoProxy:    getEnv("NO_PROXY"),
+	}
+}
+
+// Merge returns new options with the values set in toMerge overriding the ones in these options
+func (options Options) Merge(toMerge Options) Options {
+	result := options
+
+	if len(toMerge.NoProxy) > 0 {
+		result.NoProxy = toMerge.NoProxy
+	}
+
+	if toMerge.Password != "" {
+		result.Password = toMerge.Password
+	}
+
+	if toMerge.URL != "" {
+		result.URL = toMerge.URL
+	}
+
+	if toMerge.Username != "" {
+		result.Username = toMerge.Username
+	}
+
+	return result
+}
+
+// ProxyFunc creates the function that picks the proxy for each request. The proxy set in the
+// options takes precedence over the one in the environment for the scheme of the request.
+func (options Options) ProxyFunc(env Environment) (func(*http.Request) (*url.URL, error), error) {
+	proxies := make(map[string]*url.URL)
+	for scheme, proxyURL := range map[string]string{"http": env.HTTPProxy, "https": env.HTTPSProxy} {
+		parsedURL, parseErr := options.parseURL(coalesce(options.URL, proxyURL))
+		if parseErr != nil {
+			return nil, parseErr
+		}
+		proxies[scheme] = parsedURL
+	}
+
+	noProxy := options.NoProxy
+	if len(noProxy) == 0 {
+		noProxy = SplitNoProxy(env.NoProxy)
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		proxyURL := proxies[req.URL.Scheme]
+		if proxyURL == nil || matchesNoProxy(noProxy, req.URL) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}, nil
+}
+
+// SplitNoProxy splits a comma separated list of hosts, domains and networks
+func SplitNoProxy(noProxy string) []string {
+	result := make([]string, 0)
+	for _, entry := range strings.Split(noProxy, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// matchesNoProxy checks if the URL matches any of the entries that must not use the proxy. An
+// entry can be a host or IP with an optional port, a domain, which also matches its subdomains, or
+// a network in CIDR notation.
+func matchesNoProxy(noProxy []string, requestURL *url.URL) bool {
+	host := strings.ToLower(requestURL.Hostname())
+	port := requestURL.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[requestURL.Scheme]
+	}
+
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "*" {
+			return true
+		}
+
+		if _, network, cidrErr := net.ParseCIDR(entry); cidrErr == nil {
+			if ip := net.ParseIP(host); ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost := entry
+		if splitHost, splitPort, splitErr := net.SplitHostPort(entry); splitErr == nil {
+			if splitPort != port {
+				continue
+			}
+			entryHost = splitHost
+		}
+
+		entryHost = strings.TrimPrefix(strings.Trim(entryHost, "[]"), ".")
+		if host == entryHost || strings.HasSuffix(host, "."+entryHost) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseURL parses the proxy URL, defaulting to HTTP like curl does, and adds the credentials
+func (options Options) parseURL(proxyURL string) (*url.URL, error) {
+	if proxyURL == "" {
+		return nil, nil
+	}
+
+	if !strings.Contains(proxyURL, "://") {
+		proxyURL = "http://" + proxyURL
+	}
+
+	parsedURL, parseErr := url.Parse(proxyURL)
+	if parseErr != nil {
+		return nil, fmt.Errorf("Invalid proxy URL '%s': %s", proxyURL, parseErr)
+	}
+
+	switch strings.ToLower(parsedURL.Scheme) {
+	case "http", "https", "socks5":
+	case "socks5h":
+		// Go always lets the SOCKS5 proxy resolve host names
+		parsedURL.Scheme = "socks5"
+	default:
+		return nil, fmt.Errorf("Unsupported proxy scheme '%s', must be one of: http, https, socks5, socks5h", parsedURL.Scheme)
+	}
+
+	if options.Username != "" {
+		parsedURL.User = url.UserPassword(options.Username, options.Password)
+	}
+
+	return parsedURL, nil
+}
+
+func coalesce(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func getEnv(name string) string {
+	return coalesce(os.Getenv(name), os.Getenv(strings.ToLower(name)))
+}
@@ -0,0 +1,222 @@
+package request
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/visola/go-http-cli/pkg/authorization"
+	"github.com/visola/go-http-cli/pkg/netrc"
+	"github.com/visola/go-http-cli/pkg/session"
+)
+
+// requestSigner calculates the headers to be added to sign a request
+type requestSigner func(authorization.Authorization, authorization.RequestToSign) (map[string]string, error)
+
+// applyAuthorization resolves authorizations that can only be calculated when the request is
+// about to be executed and sets the resulting header in the request. Tokens are requested using the
+// token client.
+func applyAuthorization(configuredRequest Request, auth authorization.Authorization, profileNames []string, tokenClient *http.Client) (Request, error) {
+	if !auth.IsDynamic() {
+		// Static authorizations are set as headers when loading profiles, unless they depend on secrets
+		if auth.AuthorizationType == "" || hasHeader(configuredRequest.Headers, auth.ToHeaderKey()) {
+			return configuredRequest, nil
+		}
+		return applyStaticAuthorization(configuredRequest, auth)
+	}
+
+	switch strings.ToLower(auth.AuthorizationType) {
+	case authorization.AWSSigV4AuthorizationType:
+		return applySignature(configuredRequest, auth, authorization.SignAWSV4)
+	case authorization.DigestAuthorizationType:
+		return applyDigestAuthorization(configuredRequest, auth)
+	case authorization.HMACAuthorizationType:
+		return applySignature(configuredRequest, auth, authorization.SignHMAC)
+	case authorization.JWTAuthorizationType:
+		return applySignature(configuredRequest, auth, authorization.SignJWT)
+	case authorization.OAuth1AuthorizationType:
+		return applySignature(configuredRequest, auth, authorization.SignOAuth1)
+	case authorization.BasicAuthorizationType, authorization.BearerAuthorizationType:
+		// Static credentials that come from commands
+		return applyStaticAuthorization(configuredRequest, auth)
+	}
+
+	var token string
+	var tokenErr error
+
+	cacheKey := authorization.TokenCacheKey(profileNames, auth)
+	if auth.IsInteractive() {
+		token, tokenErr = getInteractiveToken(tokenClient, cacheKey, auth)
+		if tokenErr == authorization.ErrLoginRequired {
+			tokenErr = &authorization.LoginRequiredError{Auth: auth, CacheKey: cacheKey}
+		}
+	} else {
+		token, tokenErr = authorization.GetClientCredentialsToken(tokenClient, cacheKey, auth)
+	}
+
+	if tokenErr != nil {
+		return configuredRequest, tokenErr
+	}
+
+	configuredRequest.Headers[auth.ToHeaderKey()] = []string{"Bearer " + token}
+	return configuredRequest, nil
+}
+
+func applyStaticAuthorization(configuredRequest Request, auth authorization.Authorization) (Request, error) {
+	headerValue, headerErr := auth.ToHeaderValue()
+	if headerErr != nil {
+		return configuredRequest, headerErr
+	}
+
+	configuredRequest.Headers[auth.ToHeaderKey()] = []string{headerValue}
+	return configuredRequest, nil
+}
+
+func hasHeader(headers map[string][]string, name string) bool {
+	for header := range headers {
+		if strings.EqualFold(strings.TrimSpace(header), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// netrcAuthorization returns basic authorization with the credentials from the netrc file for the
+// request host. Like in curl, credentials in the URL take precedence when netrc is optional.
+func netrcAuthorization(configuredRequest Request, options netrc.Options) (authorization.Authorization, error) {
+	if !options.Enabled {
+		return authorization.Authorization{}, nil
+	}
+
+	requestURL, urlErr := buildURL(configuredRequest)
+	if urlErr != nil {
+		return authorization.Authorization{}, urlErr
+	}
+
+	if options.Optional && requestURL.User != nil {
+		return authorization.Authorization{}, nil
+	}
+
+	machine, machineErr := netrc.FindMachine(options, requestURL.Hostname())
+	if machineErr != nil || machine == nil || machine.Login == "" || machine.Password == "" {
+		return authorization.Authorization{}, machineErr
+	}
+
+	return authorization.Authorization{
+		AuthorizationType: authorization.BasicAuthorizationType,
+		Password:          machine.Password,
+		Username:          machine.Login,
+	}, nil
+}
+
+// getInteractiveToken returns the access token stored in the session, refreshing it if it expired.
+// If no valid token is available, the user needs to login again.
+func getInteractiveToken(tokenClient *http.Client, cacheKey string, auth authorization.Authorization) (string, error) {
+	tokens, exists := session.GetTokens(cacheKey)
+	if !exists {
+		return "", authorization.ErrLoginRequired
+	}
+
+	if !tokens.IsExpired() {
+		return tokens.AccessToken, nil
+	}
+
+	if tokens.RefreshToken == "" {
+		return "", authorization.ErrLoginRequired
+	}
+
+	refreshedTokens, refreshErr := authorization.RefreshAccessToken(tokenClient, auth, tokens.RefreshToken)
+	if refreshErr != nil {
+		log.Warningf("Error while refreshing token: %s", refreshErr)
+		return "", authorization.ErrLoginRequired
+	}
+
+	session.SetTokens(cacheKey, *refreshedTokens)
+	return refreshedTokens.AccessToken, nil
+}
+
+// applySignature signs the request using the signer, it must be the last thing done before
+// sending the request
+func applySignature(configuredRequest Request, auth authorization.Authorization, signer requestSigner) (Request, error) {
+	toSign, toSignErr := createRequestToSign(configuredRequest)
+	if toSignErr != nil {
+		return configuredRequest, toSignErr
+	}
+
+	signedHeaders, signErr := signer(auth, *toSign)
+	if signErr != nil {
+		return configuredRequest, signErr
+	}
+
+	for name, value := range signedHeaders {
+		configuredRequest.Headers[name] = []string{value}
+	}
+
+	return configuredRequest, nil
+}
+
+// applyDigestAuthorization reuses the last challenge received from the host, if any
+func applyDigestAuthorization(configuredRequest Request, auth authorization.Authorization) (Request, error) {
+	requestURL, urlErr := buildURL(configuredRequest)
+	if urlErr != nil {
+		return configuredRequest, urlErr
+	}
+
+	method := configuredRequest.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	if headerValue, hasChallenge := authorization.NextDigestAuthorization(requestURL.Host, auth, method, requestURL.RequestURI()); hasChallenge {
+		configuredRequest.Headers[auth.ToHeaderKey()] = []string{headerValue}
+	}
+
+	return configuredRequest, nil
+}
+
+func createRequestToSign(configuredRequest Request) (*authorization.RequestToSign, error) {
+	requestURL, urlErr := buildURL(configuredRequest)
+	if urlErr != nil {
+		return nil, urlErr
+	}
+
+	method := configuredRequest.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	return &authorization.RequestToSign{
+		Body:         string(configuredRequest.Body),
+		Headers:      configuredRequest.Headers,
+		Method:       method,
+		StreamedBody: configuredRequest.BodyFile != "" || len(configuredRequest.Multipart) > 0,
+		Time:         time.Now(),
+		URL:          requestURL,
+	}, nil
+}
+
+func isDigest(auth authorization.Authorization) bool {
+	return strings.ToLower(auth.AuthorizationType) == authorization.DigestAuthorizationType
+}
+
+// respondToChallenge checks if the response is a challenge for the authorization. If it is, it
+// returns the request authorized with the response to the challenge, ready to be sent again.
+func respondToChallenge(configuredRequest Request, response *Response, auth authorization.Authorization) (Request, bool, error) {
+	if !isDigest(auth) || response.StatusCode != http.StatusUnauthorized {
+		return configuredRequest, false, nil
+	}
+
+	challenge := authorization.ParseDigestChallenge(response.Headers[http.CanonicalHeaderKey("WWW-Authenticate")])
+	if challenge == nil {
+		return configuredRequest, false, nil
+	}
+
+	requestURL, urlErr := buildURL(configuredRequest)
+	if urlErr != nil {
+		return configuredRequest, false, urlErr
+	}
+
+	authorization.SetDigestChallenge(requestURL.Host, auth, *challenge)
+	authorizedRequest, authErr := applyDigestAuthorization(configuredRequest, auth)
+	return authorizedRequest, true, authErr
+}
@@ -0,0 +1,413 @@
+package request
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visola/go-http-cli/pkg/authorization"
+	"github.com/visola/go-http-cli/pkg/credential"
+	"github.com/visola/go-http-cli/pkg/netrc"
+	"github.com/visola/go-http-cli/pkg/profile"
+	"github.com/visola/go-http-cli/pkg/secrets"
+	"github.com/visola/go-http-cli/pkg/session"
+)
+
+func TestApplyAuthorization(t *testing.T) {
+	t.Run("Requires login without tokens", testRequiresLoginWithoutTokens)
+	t.Run("Uses stored token", testUsesStoredToken)
+	t.Run("Refreshes expired token", testRefreshesExpiredToken)
+	t.Run("Responds to digest challenge", testRespondsToDigestChallenge)
+	t.Run("Uses credentials from commands", testUsesCredentialsFromCommands)
+	t.Run("Runs commands only from profiles", testRunsCommandsOnlyFromProfiles)
+	t.Run("Uses credentials from secrets", testUsesCredentialsFromSecrets)
+	t.Run("Replaces session variables in auth", testReplacesSessionVariablesInAuth)
+	t.Run("Named request overrides auth", testNamedRequestOverridesAuth)
+	t.Run("Uses credentials from netrc", testUsesCredentialsFromNetrc)
+	t.Run("Mints JWT with key relative to profiles dir", testMintsJWTWithRelativeKeyFile)
+}
+
+func testRequiresLoginWithoutTokens(t *testing.T) {
+	auth := createInteractiveAuth("http://localhost/token")
+
+	_, err := applyAuthorization(Request{Headers: map[string][]string{}}, auth, []string{"no-tokens"}, http.DefaultClient)
+	assert.ErrorIs(t, err, authorization.ErrLoginRequired, "Should require login")
+
+	var loginErr *authorization.LoginRequiredError
+	require.ErrorAs(t, err, &loginErr, "Should say how to login")
+	assert.Equal(t, auth, loginErr.Auth, "Should carry the resolved authorization")
+	assert.Equal(t, authorization.TokenCacheKey([]string{"no-tokens"}, auth), loginErr.CacheKey, "Should carry the key to store tokens with")
+}
+
+func testUsesStoredToken(t *testing.T) {
+	auth := createInteractiveAuth("http://localhost/token")
+	profiles := []string{"stored"}
+	session.SetTokens(authorization.TokenCacheKey(profiles, auth), authorization.Tokens{
+		AccessToken: "stored-token",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+
+	authorizedRequest, err := applyAuthorization(Request{Headers: map[string][]string{}}, auth, profiles, http.DefaultClient)
+	assert.Nil(t, err, "Should not return error")
+	assert.Equal(t, []string{"Bearer stored-token"}, authorizedRequest.Headers["Authorization"], "Should use stored token")
+}
+
+func testRefreshesExpiredToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		assert.Equal(t, "refresh_token", r.PostForm.Get("grant_type"), "Should use refresh token grant")
+		assert.Equal(t, "refresh-me", r.PostForm.Get("refresh_token"), "Should send refresh token")
+		fmt.Fprint(w, `{"access_token":"refreshed-token","expires_in":3600}`)
+	}))
+	defer server.Close()
+
+	auth := createInteractiveAuth(server.URL)
+	profiles := []string{"expired"}
+	cacheKey := authorization.TokenCacheKey(profiles, auth)
+	session.SetTokens(cacheKey, authorization.Tokens{
+		AccessToken:  "expired-token",
+		ExpiresAt:    time.Now().Add(-time.Minute),
+		RefreshToken: "refresh-me",
+	})
+
+	authorizedRequest, err := applyAuthorization(Request{Headers: map[string][]string{}}, auth, profiles, http.DefaultClient)
+	assert.Nil(t, err, "Should not return error")
+	assert.Equal(t, []string{"Bearer refreshed-token"}, authorizedRequest.Headers["Authorization"], "Should use refreshed token")
+
+	storedTokens, _ := session.GetTokens(cacheKey)
+	assert.Equal(t, "refreshed-token", storedTokens.AccessToken, "Should store refreshed token")
+	assert.Equal(t, "refresh-me", storedTokens.RefreshToken, "Should keep refresh token")
+}
+
+func createInteractiveAuth(tokenURL string) authorization.Authorization {
+	return authorization.Authorization{
+		AuthorizationType: authorization.OAuth2AuthorizationCodeAuthorizationType,
+		AuthorizationURL:  "http://localhost/authorize",
+		ClientID:          "my-client",
+		TokenURL:          tokenURL,
+	}
+}
+
+func testRespondsToDigestChallenge(t *testing.T) {
+	receivedAuthorizations := make([]string, 0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizationHeader := r.Header.Get("Authorization")
+		receivedAuthorizations = append(receivedAuthorizations, authorizationHeader)
+		if !strings.HasPrefix(authorizationHeader, "Digest ") {
+			w.Header().Set("WWW-Authenticate", `Digest realm="test", qop="auth", algorithm=SHA-256, nonce="some-nonce"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, "Welcome!")
+	}))
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("digest", "auth:\n  type: digest\n  username: someone\n  password: secret\n", profilesDir)
+
+	executionContext := ExecutionContext{
+		ProfileNames: []string{"digest"},
+		Request: Request{
+			Method: http.MethodGet,
+			URL:    server.URL + "/protected",
+		},
+	}
+
+	executedRequestResponses, err := ExecuteRequestLoop(executionContext)
+	assert.Nil(t, err, "Should execute request")
+	assert.Equal(t, 1, len(executedRequestResponses), "Should only record authorized request")
+	assert.Equal(t, http.StatusOK, executedRequestResponses[0].Response.StatusCode, "Should be authorized")
+	assert.Equal(t, 2, len(receivedAuthorizations), "Should resend request after challenge")
+	assert.Contains(t, receivedAuthorizations[1], "nc=00000001", "Should start nonce count")
+	assert.Contains(t, receivedAuthorizations[1], `uri="/protected"`, "Should use request URI")
+
+	_, err = ExecuteRequestLoop(executionContext)
+	assert.Nil(t, err, "Should execute request")
+	assert.Equal(t, 3, len(receivedAuthorizations), "Should reuse nonce without a new challenge")
+	assert.Contains(t, receivedAuthorizations[2], "nc=00000002", "Should increment nonce count")
+}
+
+func testUsesCredentialsFromCommands(t *testing.T) {
+	var receivedRequest *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequest = r
+	}))
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("commands", `auth:
+  type: basic
+  username: someone
+  passwordCommand: echo password-from-command
+variables:
+  apiKey:
+    command: echo key-from-command
+    ttl: 0s
+`, profilesDir)
+
+	executionContext := ExecutionContext{
+		ProfileNames: []string{"commands"},
+		Request: Request{
+			Headers: map[string][]string{"X-Api-Key": {"{apiKey}"}},
+			Method:  http.MethodGet,
+			URL:     server.URL,
+		},
+	}
+
+	_, err := ExecuteRequestLoop(executionContext)
+	assert.Nil(t, err, "Should execute request")
+
+	_, password, _ := receivedRequest.BasicAuth()
+	assert.Equal(t, "password-from-command", password, "Should use password from command")
+	assert.Equal(t, "key-from-command", receivedRequest.Header.Get("X-Api-Key"), "Should use variable from command")
+}
+
+func testRunsCommandsOnlyFromProfiles(t *testing.T) {
+	var receivedRequest *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequest = r
+	}))
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("request-commands", `requests:
+  withCommand:
+    auth:
+      type: bearer
+      tokenCommand: echo token-from-command
+`, profilesDir)
+
+	mergedProfile, profileErr := profile.LoadAndMergeProfiles([]string{"request-commands"})
+	require.Nil(t, profileErr, "Should load profile")
+
+	configuredRequest, configureErr := ConfigureRequest(
+		Request{URL: server.URL},
+		&mergedProfile,
+		CreateConfigureRequestOptions(AddProfiles("request-commands"), SetRequestName("withCommand")),
+	)
+	require.Nil(t, configureErr, "Should configure request")
+
+	// Execution contexts are sent to the daemon as JSON
+	sendToDaemon := func(executionContext ExecutionContext) ExecutionContext {
+		data, marshalErr := json.Marshal(executionContext)
+		require.Nil(t, marshalErr, "Should marshal execution context")
+		assert.NotContains(t, string(data), "echo", "Should not send commands")
+
+		var received ExecutionContext
+		require.Nil(t, json.Unmarshal(data, &received), "Should unmarshal execution context")
+		return received
+	}
+
+	_, err := ExecuteRequestLoop(sendToDaemon(ExecutionContext{
+		ProfileNames: []string{"request-commands"},
+		Request:      *configuredRequest,
+		RequestName:  "withCommand",
+	}))
+	assert.Nil(t, err, "Should execute request")
+	assert.Equal(t, "Bearer token-from-command", receivedRequest.Header.Get("Authorization"), "Should run command from named request in profile")
+
+	configuredRequest.Auth.TokenCommand = credential.Command{Command: "echo token-from-request"}
+	_, err = ExecuteRequestLoop(sendToDaemon(ExecutionContext{
+		ProfileNames: []string{"request-commands"},
+		Request:      *configuredRequest,
+	}))
+	assert.NotNil(t, err, "Should not run command that didn't come from a profile")
+}
+
+func testUsesCredentialsFromSecrets(t *testing.T) {
+	var receivedRequest *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequest = r
+	}))
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("secure", "auth:\n  type: basic\n  username: someone\n", profilesDir)
+	profile.WriteSecrets("secure", "my passphrase", []byte("auth:\n  password: password-from-secrets\n"))
+
+	executionContext := ExecutionContext{
+		ProfileNames: []string{"secure"},
+		Request: Request{
+			Method: http.MethodGet,
+			URL:    server.URL,
+		},
+	}
+
+	_, err := ExecuteRequestLoop(executionContext)
+	assert.Equal(t, secrets.ErrLocked, err, "Should require secrets to be unlocked")
+
+	secrets.Unlock("my passphrase")
+	defer secrets.Lock()
+
+	_, err = ExecuteRequestLoop(executionContext)
+	assert.Nil(t, err, "Should execute request")
+
+	_, password, _ := receivedRequest.BasicAuth()
+	assert.Equal(t, "password-from-secrets", password, "Should use password from secrets")
+}
+
+func testReplacesSessionVariablesInAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			fmt.Fprint(w, "token-from-login")
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer token-from-login" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("session-auth", fmt.Sprintf(`baseURL: %s
+auth:
+  type: bearer
+  token: '{sessionAccessToken}'
+onUnauthorized: login
+requests:
+  login:
+    url: /login
+    postProcessScript: addVariable('sessionAccessToken', response.Body);
+`, server.URL), profilesDir)
+
+	executedRequestResponses, err := executeWithProfile(t, "session-auth", "", server.URL+"/data")
+	assert.Nil(t, err, "Should execute request")
+	assert.Equal(t, 3, len(executedRequestResponses), "Should execute request, login and replay")
+
+	replayed := executedRequestResponses[2]
+	assert.Equal(t, http.StatusOK, replayed.Response.StatusCode, "Should be authorized after login")
+	assert.Equal(t, "Bearer token-from-login", replayed.Request.Headers["Authorization"][0], "Should use token from session")
+}
+
+func testNamedRequestOverridesAuth(t *testing.T) {
+	var receivedRequest *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedRequest = r
+	}))
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("request-auth", `auth:
+  type: basic
+  username: someone
+  password: profile-password
+requests:
+  samePassword:
+    auth:
+      username: someone-else
+  otherType:
+    auth:
+      type: bearer
+      token: request-token
+`, profilesDir)
+
+	_, err := executeWithProfile(t, "request-auth", "samePassword", server.URL)
+	assert.Nil(t, err, "Should execute request")
+
+	username, password, _ := receivedRequest.BasicAuth()
+	assert.Equal(t, "someone-else", username, "Should use username from named request")
+	assert.Equal(t, "profile-password", password, "Should keep password from profile")
+
+	_, err = executeWithProfile(t, "request-auth", "otherType", server.URL)
+	assert.Nil(t, err, "Should execute request")
+	assert.Equal(t, "Bearer request-token", receivedRequest.Header.Get("Authorization"), "Should replace auth from profile")
+}
+
+func testUsesCredentialsFromNetrc(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	netrcDir, _ := ioutil.TempDir("", "netrc")
+	defer os.RemoveAll(netrcDir)
+
+	netrcFile := filepath.Join(netrcDir, ".netrc")
+	ioutil.WriteFile(netrcFile, []byte("machine 127.0.0.1 login from-netrc password netrc-password\n"), 0600)
+
+	requestWithURL := func(requestURL string) Request {
+		return Request{Headers: map[string][]string{}, URL: requestURL}
+	}
+
+	auth, err := netrcAuthorization(requestWithURL(server.URL), netrc.Options{Enabled: true, File: netrcFile})
+	assert.Nil(t, err, "Should load netrc")
+	assert.Equal(t, "from-netrc", auth.Username, "Should use login for request host")
+	assert.Equal(t, "netrc-password", auth.Password, "Should use password for request host")
+
+	urlWithCredentials := strings.Replace(server.URL, "http://", "http://someone:pass@", 1)
+	auth, _ = netrcAuthorization(requestWithURL(urlWithCredentials), netrc.Options{Enabled: true, File: netrcFile, Optional: true})
+	assert.Equal(t, "", auth.AuthorizationType, "Should prefer credentials in the URL when netrc is optional")
+
+	auth, _ = netrcAuthorization(requestWithURL(urlWithCredentials), netrc.Options{Enabled: true, File: netrcFile})
+	assert.Equal(t, "from-netrc", auth.Username, "Should prefer netrc credentials when netrc is required")
+
+	_, err = netrcAuthorization(requestWithURL(server.URL), netrc.Options{Enabled: true, File: netrcFile + ".missing"})
+	assert.NotNil(t, err, "Should fail if required netrc file is missing")
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("with-auth", "auth:\n  type: bearer\n  token: profile-token\n", profilesDir)
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		Netrc:        netrc.Options{Enabled: true, File: netrcFile},
+		ProfileNames: []string{"with-auth"},
+		Request:      Request{Method: http.MethodGet, URL: server.URL},
+	})
+	assert.Nil(t, err, "Should execute request")
+	assert.Equal(t, "Bearer profile-token", executedRequestResponses[0].Request.Headers["Authorization"][0], "Should use auth from profile over netrc")
+
+	executedRequestResponses, err = ExecuteRequestLoop(ExecutionContext{
+		Netrc:   netrc.Options{Enabled: true, File: netrcFile},
+		Request: Request{Method: http.MethodGet, URL: server.URL},
+	})
+	assert.Nil(t, err, "Should execute request")
+	assert.Equal(t, "Basic ZnJvbS1uZXRyYzpuZXRyYy1wYXNzd29yZA==", executedRequestResponses[0].Request.Headers["Authorization"][0], "Should send basic auth from netrc")
+}
+
+func testMintsJWTWithRelativeKeyFile(t *testing.T) {
+	profilesDir := profile.SetupTestProfilesDir()
+	privateKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ecKey, _ := x509.MarshalECPrivateKey(privateKey)
+	ioutil.WriteFile(filepath.Join(profilesDir, "service.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecKey}), 0600)
+
+	profile.CreateTestProfile("jwt", `auth:
+  type: jwt
+  keyFile: service.pem
+  keyId: service-key
+  claims:
+    iss: '{serviceName}'
+    exp: 1m
+variables:
+  serviceName: my-service
+`, profilesDir)
+
+	var tokens []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		_, err := executeWithProfile(t, "jwt", "", server.URL)
+		assert.Nil(t, err, "Should execute request")
+	}
+
+	assert.Equal(t, 2, len(tokens), "Should send two requests")
+	assert.NotEqual(t, tokens[0], tokens[1], "Should mint a new token for each request")
+
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(strings.Split(tokens[0], ".")[1])
+	assert.Contains(t, string(claimsJSON), `"iss":"my-service"`, "Should replace variables in claims")
+}
@@ -1,32 +1,25 @@
 package request
 
 import (
+	"errors"
+	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
 
+	"github.com/visola/go-http-cli/pkg/formdata"
 	"github.com/visola/go-http-cli/pkg/ioutil"
+	"github.com/visola/go-http-cli/pkg/upload"
 )
 
 // BuildRequest builds an http.Request from a configured request.Request
 func BuildRequest(processedRequest Request) (*http.Request, error) {
-	parsedURL, urlError := url.Parse(processedRequest.URL)
+	parsedURL, urlError := buildURL(processedRequest)
 	if urlError != nil {
 		return nil, urlError
 	}
 
-	rawQueryPieces := make([]string, 0)
-	if parsedURL.RawQuery != "" {
-		rawQueryPieces = append(rawQueryPieces, parsedURL.RawQuery)
-	}
-
-	encodedQueryFromValues := encodeValues(processedRequest.QueryParams)
-	if encodedQueryFromValues != "" {
-		rawQueryPieces = append(rawQueryPieces, encodedQueryFromValues)
-	}
-
-	parsedURL.RawQuery = strings.Join(rawQueryPieces, "&")
-
 	req, reqErr := http.NewRequest(processedRequest.Method, parsedURL.String(), nil)
 	if reqErr != nil {
 		return nil, reqErr
@@ -42,6 +35,80 @@ func BuildRequest(processedRequest Request) (*http.Request, error) {
 		}
 	}
 
-	req.Body = ioutil.CreateCloseableBufferString(processedRequest.Body)
+	if processedRequest.BodyFile != "" {
+		return req, streamBodyFile(req, processedRequest.BodyFile)
+	}
+
+	if len(processedRequest.Multipart) > 0 {
+		return req, streamMultipart(req, processedRequest.Multipart)
+	}
+
+	req.Body = ioutil.CreateCloseableBuffer(processedRequest.Body)
 	return req, nil
 }
+
+// streamBodyFile sets the file as the body of the request. The size of regular files is sent in the
+// Content-Length header, other files, like pipes, are sent with chunked encoding.
+func streamBodyFile(req *http.Request, bodyFile string) error {
+	body, size, openErr := upload.Open(bodyFile)
+	if openErr != nil {
+		return openErr
+	}
+
+	req.Body = body
+	req.ContentLength = size
+	if size >= 0 {
+		// Lets the request be sent again if the connection is closed before it starts
+		req.GetBody = func() (io.ReadCloser, error) {
+			reopened, _, reopenErr := upload.Open(bodyFile)
+			return reopened, reopenErr
+		}
+	}
+	return nil
+}
+
+// streamMultipart sets the multipart form as the body of the request, using the boundary from the
+// Content-Type header. Like files, forms are sent with chunked encoding if any file is a pipe.
+func streamMultipart(req *http.Request, parts []formdata.Part) error {
+	_, params, parseErr := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if parseErr != nil || params["boundary"] == "" {
+		return errors.New("Multipart form requires a Content-Type header with a boundary")
+	}
+
+	body, size, bodyErr := formdata.Body(parts, params["boundary"])
+	if bodyErr != nil {
+		return bodyErr
+	}
+
+	req.Body = body
+	req.ContentLength = size
+	if size >= 0 {
+		// Lets the request be sent again if the connection is closed before it starts
+		req.GetBody = func() (io.ReadCloser, error) {
+			rebuilt, _, rebuildErr := formdata.Body(parts, params["boundary"])
+			return rebuilt, rebuildErr
+		}
+	}
+	return nil
+}
+
+// buildURL builds the final URL for a request, including the query parameters
+func buildURL(processedRequest Request) (*url.URL, error) {
+	parsedURL, urlError := url.Parse(processedRequest.URL)
+	if urlError != nil {
+		return nil, urlError
+	}
+
+	rawQueryPieces := make([]string, 0)
+	if parsedURL.RawQuery != "" {
+		rawQueryPieces = append(rawQueryPieces, parsedURL.RawQuery)
+	}
+
+	encodedQueryFromValues := encodeValues(processedRequest.QueryParams)
+	if encodedQueryFromValues != "" {
+		rawQueryPieces = append(rawQueryPieces, encodedQueryFromValues)
+	}
+
+	parsedURL.RawQuery = strings.Join(rawQueryPieces, "&")
+	return parsedURL, nil
+}
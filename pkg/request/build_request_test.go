@@ -14,7 +14,7 @@ func TestBuildRequest(t *testing.T) {
 
 func testBuildsRequestCorrectly(t *testing.T) {
 	request := Request{
-		Body:   `{"username":"John Doe"}`,
+		Body:   []byte(`{"username":"John Doe"}`),
 		Method: http.MethodPost,
 		QueryParams: map[string][]string{
 			"auth": {"4312763812&*&%&$%!^@#+123"},
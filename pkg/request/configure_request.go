@@ -3,10 +3,12 @@ package request
 import (
 	"encoding/json"
 	"fmt"
+	"mime"
 	"net/http"
 	"strings"
 
 	"github.com/visola/go-http-cli/pkg/base"
+	"github.com/visola/go-http-cli/pkg/formdata"
 	"github.com/visola/go-http-cli/pkg/profile"
 )
 
@@ -63,18 +65,26 @@ func ConfigureRequest(unconfiguredRequest Request, mergedProfile *profile.Option
 }
 
 func finalizeConfiguringRequest(configuredRequest Request, mergedProfile *profile.Options, namedRequest profile.NamedRequest, finalValueSet map[string][]string) (*Request, error) {
-	hasBody := configuredRequest.Body != ""
+	hasMultipart := len(configuredRequest.Multipart) > 0
+	hasBody := len(configuredRequest.Body) > 0 || configuredRequest.BodyFile != "" || hasMultipart
 	hasValues := len(finalValueSet) > 0
 	hasContentType := getContentType(configuredRequest.Headers) != ""
 
 	configuredRequest.Method = getMethod(configuredRequest.Method, hasBody)
 
-	if !hasContentType && (hasBody || (hasValues && configuredRequest.Method != http.MethodGet)) {
+	if hasMultipart {
+		setMultipartContentType(configuredRequest.Headers)
+	} else if !hasContentType && (hasBody || (hasValues && configuredRequest.Method != http.MethodGet)) {
 		configuredRequest.Headers["Content-Type"] = []string{jsonMimeType}
 	}
 
 	var createdFromValues bool
 	configuredRequest.Body, createdFromValues = getBody(configuredRequest, finalValueSet)
+	if configuredRequest.Method == http.MethodGet {
+		configuredRequest.BodyFile = ""
+		configuredRequest.Multipart = nil
+	}
+
 	configuredRequest.URL = ParseURL(mergedProfile.BaseURL, configuredRequest.URL, namedRequest.URL)
 
 	if !createdFromValues && len(finalValueSet) > 0 {
@@ -116,20 +126,20 @@ func createBody(processedRequest Request, values map[string][]string) string {
 	return fmt.Sprintf("Unsupported body type: %s", contentType)
 }
 
-func getBody(configuredRequest Request, values map[string][]string) (string, bool) {
+func getBody(configuredRequest Request, values map[string][]string) ([]byte, bool) {
 	if configuredRequest.Method == http.MethodGet {
-		return "", false
+		return nil, false
 	}
 
-	if configuredRequest.Body != "" {
+	if len(configuredRequest.Body) > 0 || configuredRequest.BodyFile != "" || len(configuredRequest.Multipart) > 0 {
 		return configuredRequest.Body, false
 	}
 
 	if len(values) > 0 {
-		return createBody(configuredRequest, values), true
+		return []byte(createBody(configuredRequest, values)), true
 	}
 
-	return "", false
+	return nil, false
 }
 
 func getMethod(currentMethod string, hasBody bool) string {
@@ -146,6 +156,22 @@ func getMethod(currentMethod string, hasBody bool) string {
 	return http.MethodGet
 }
 
+// setMultipartContentType sets the content type for a multipart form with a new boundary, unless a
+// multipart content type with a boundary is already set
+func setMultipartContentType(headers map[string][]string) {
+	mediaType, params, _ := mime.ParseMediaType(getContentType(headers))
+	if strings.HasPrefix(mediaType, "multipart/") && params["boundary"] != "" {
+		return
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		mediaType = formdata.MimeType
+	}
+
+	deleteHeaders(headers, "Content-Type")
+	headers["Content-Type"] = []string{mediaType + "; boundary=" + formdata.NewBoundary()}
+}
+
 func getValues(valueSets ...base.WithValues) map[string][]string {
 	result := make(map[string][]string)
 	for _, valueSet := range valueSets {
@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/visola/go-http-cli/pkg/formdata"
 	"github.com/visola/go-http-cli/pkg/profile"
 )
 
@@ -15,6 +16,8 @@ func TestConfigureRequest(t *testing.T) {
 	t.Run("Test with values", testWithValues)
 	t.Run("Test POST with values", testPostWithValues)
 	t.Run("Test with body and values", testWithBodyAndValues)
+	t.Run("Test with body file and values", testWithBodyFileAndValues)
+	t.Run("Test with multipart form", testWithMultipartForm)
 	t.Run("Test with profiles", testConfigureFromProfile)
 }
 
@@ -36,7 +39,7 @@ func testSimpleRequest(t *testing.T) {
 
 func testWithBody(t *testing.T) {
 	req := Request{
-		Body: "Hello server!",
+		Body: []byte("Hello server!"),
 		URL:  "http://www.someserver.com/some/path",
 	}
 
@@ -72,7 +75,7 @@ func testPostWithValues(t *testing.T) {
 
 	assert.Equal(t, req.URL, configureRequest.URL, "Should set passed in URL")
 	assert.Equal(t, req.Method, configureRequest.Method, "Should be set to method passed in")
-	assert.Equal(t, `{"age":"20","name":"John"}`, configureRequest.Body, "Should set body as JSON")
+	assert.Equal(t, `{"age":"20","name":"John"}`, string(configureRequest.Body), "Should set body as JSON")
 }
 
 func testWithValues(t *testing.T) {
@@ -99,7 +102,7 @@ func testWithValues(t *testing.T) {
 
 func testWithBodyAndValues(t *testing.T) {
 	req := Request{
-		Body: "Hello server!",
+		Body: []byte("Hello server!"),
 		URL:  "http://www.someserver.com/some/path",
 	}
 
@@ -121,6 +124,51 @@ func testWithBodyAndValues(t *testing.T) {
 	assert.Equal(t, values, configureRequest.QueryParams, "Should set query params")
 }
 
+func testWithBodyFileAndValues(t *testing.T) {
+	req := Request{
+		BodyFile: "/tmp/dump.sql",
+		URL:      "http://www.someserver.com/some/path",
+	}
+
+	values := map[string][]string{
+		"name": []string{"{name}"},
+	}
+
+	configureRequest, err := ConfigureRequest(req, &profile.Options{}, CreateConfigureRequestOptions(AddValues(values)))
+
+	assert.Nil(t, err, "Should not return an error")
+	if err != nil {
+		return
+	}
+
+	assert.Equal(t, http.MethodPost, configureRequest.Method, "Should be set to POST")
+	assert.Empty(t, configureRequest.Body, "Should not build body from values")
+	assert.Equal(t, req.BodyFile, configureRequest.BodyFile, "Should keep file to stream")
+	assert.Equal(t, values, configureRequest.QueryParams, "Should set query params")
+}
+
+func testWithMultipartForm(t *testing.T) {
+	req := Request{
+		Multipart: []formdata.Part{{Name: "name", Value: "John Doe"}},
+		URL:       "http://www.someserver.com/some/path",
+	}
+
+	testProfile := &profile.Options{
+		Headers: map[string][]string{"Content-Type": {"application/json"}},
+	}
+
+	configureRequest, err := ConfigureRequest(req, testProfile, CreateConfigureRequestOptions())
+
+	assert.Nil(t, err, "Should not return an error")
+	if err != nil {
+		return
+	}
+
+	assert.Equal(t, http.MethodPost, configureRequest.Method, "Should be set to POST")
+	assert.Regexp(t, "^multipart/form-data; boundary=[0-9a-f]+$", configureRequest.Headers["Content-Type"][0], "Should replace content type with a boundary")
+	assert.Equal(t, req.Multipart, configureRequest.Multipart, "Should keep the form")
+}
+
 func testConfigureFromProfile(t *testing.T) {
 	testProfile := &profile.Options{
 		BaseURL: "http://www.someserver.com/",
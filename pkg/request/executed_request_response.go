@@ -1,10 +1,23 @@
 package request
 
+import "time"
+
 // ExecutedRequestResponse represents a pair of request and the response that was returned from its
 // execution. It also includes any output and/or error generated during post processing.
 type ExecutedRequestResponse struct {
 	Request           Request
 	Response          Response
+	Error             string // Error from an attempt that failed without a response and was retried
 	PostProcessError  string
 	PostProcessOutput string
+	RetryDelay        time.Duration // How long it waited before retrying, if this attempt was retried
+	RetryReason       string        // Status code or condition that caused this attempt to be retried
+}
+
+// toScript converts the pair to what post processing scripts get
+func (executed ExecutedRequestResponse) toScript() map[string]interface{} {
+	fields := scriptFields(executed)
+	fields["Request"] = executed.Request.toScript()
+	fields["Response"] = executed.Response.toScript()
+	return fields
 }
@@ -1,15 +1,29 @@
 package request
 
-import "github.com/visola/go-http-cli/pkg/session"
+import (
+	"time"
+
+	"github.com/visola/go-http-cli/pkg/download"
+	"github.com/visola/go-http-cli/pkg/netrc"
+	"github.com/visola/go-http-cli/pkg/progress"
+	"github.com/visola/go-http-cli/pkg/proxy"
+	"github.com/visola/go-http-cli/pkg/session"
+)
 
 // ExecutionContext represent the options to be passed for the request executor.
 type ExecutionContext struct {
 	AllowInsecure    bool
+	Download         download.Options
 	FollowLocation   bool
 	MaxAddedRequests int
 	MaxRedirect      int
+	MaxTime          time.Duration // Time budget for all requests executed, zero means no limit
+	Netrc            netrc.Options
+	OnProgress       func(progress.Progress) `json:"-"` // Called while sending and saving bodies
 	ProfileNames     []string
+	ProxyEnvironment proxy.Environment // Proxy environment variables from where the request was made
 	Request          Request
+	RequestName      string // Named request the request was configured from, if any
 	Session          *session.Session
 	Variables        map[string]string
 }
@@ -1,18 +1,32 @@
 package request
 
 import (
-	"crypto/tls"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
+	"github.com/visola/go-http-cli/pkg/authorization"
+	"github.com/visola/go-http-cli/pkg/download"
 	"github.com/visola/go-http-cli/pkg/profile"
+	"github.com/visola/go-http-cli/pkg/protocol"
+	"github.com/visola/go-http-cli/pkg/secrets"
 	"github.com/visola/go-http-cli/pkg/session"
+	"github.com/visola/go-http-cli/pkg/timeout"
+	"github.com/visola/go-http-cli/pkg/tlsconfig"
+	"github.com/visola/go-http-cli/pkg/upload"
 	"github.com/visola/variables/variables"
 )
 
+// Token endpoints can't take longer than this when the request doesn't set a timeout
+const defaultTokenTimeout = 30 * time.Second
+
 // ExecuteRequestLoop executes HTTP requests based on the passed in options until there're no more
 // requests to be executed.
 func ExecuteRequestLoop(executionContext ExecutionContext) ([]ExecutedRequestResponse, error) {
@@ -23,8 +37,44 @@ func ExecuteRequestLoop(executionContext ExecutionContext) ([]ExecutedRequestRes
 		return nil, profileError
 	}
 
+	if mergedProfiles.SecretsLocked {
+		return nil, secrets.ErrLocked
+	}
+
+	var commandsErr error
+	mergedProfiles.Variables, commandsErr = resolveVariableCommands(mergedProfiles)
+	if commandsErr != nil {
+		return nil, commandsErr
+	}
+
+	// Secrets are only loaded by the daemon, so they complete the auth configured for the request.
+	// Commands never come with the request, they are only run from the profiles loaded here.
+	profileAuth, authErr := mergedProfiles.GetAuth()
+	if authErr != nil {
+		return nil, authErr
+	}
+
+	namedRequest, namedRequestErr := profile.FindNamedRequest(&mergedProfiles, executionContext.RequestName)
+	if namedRequestErr != nil {
+		return nil, namedRequestErr
+	}
+
+	namedRequestAuth, namedRequestAuthErr := namedRequest.GetAuth()
+	if namedRequestAuthErr != nil {
+		return nil, namedRequestAuthErr
+	}
+	executionContext.Request.Auth = profileAuth.Merge(namedRequestAuth).Merge(executionContext.Request.Auth)
+
 	initialVariables := mergeVariables(executionContext.Variables, mergedProfiles.Variables)
 
+	// The budget limits the time for all requests executed in this loop
+	budget := context.Background()
+	if executionContext.MaxTime > 0 {
+		var cancelBudget context.CancelFunc
+		budget, cancelBudget = context.WithTimeout(budget, executionContext.MaxTime)
+		defer cancelBudget()
+	}
+
 	requestsToExecute := []Request{executionContext.Request}
 	result := make([]ExecutedRequestResponse, 0)
 	redirectCount := 0
@@ -33,34 +83,57 @@ func ExecuteRequestLoop(executionContext ExecutionContext) ([]ExecutedRequestRes
 		currentConfiguredRequest := requestsToExecute[0]
 		requestsToExecute = requestsToExecute[1:]
 
+		if budgetErr := budgetError(budget, executionContext.MaxTime, nil); budgetErr != nil {
+			return result, budgetErr
+		}
+
 		var sessionErr error
 		executionContext.Session, sessionErr = loadSessionForRequest(variables.ReplaceVariables(currentConfiguredRequest.URL, initialVariables))
 		if sessionErr != nil {
 			return nil, sessionErr
 		}
 
-		currentConfiguredRequest, replaceVariablesError := replaceRequestVariables(currentConfiguredRequest, mergedProfiles, executionContext)
-		if replaceVariablesError != nil {
-			return nil, replaceVariablesError
+		attempts, executeErr := executeWithRetries(budget, client, currentConfiguredRequest, mergedProfiles, executionContext)
+		if executeErr != nil {
+			return append(result, attempts...), budgetError(budget, executionContext.MaxTime, executeErr)
 		}
 
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: executionContext.AllowInsecure || currentConfiguredRequest.AllowInsecure},
-		}
+		// The last attempt is the one to process, the ones before it were retried
+		requestResponse := &attempts[len(attempts)-1]
+		result = append(result, attempts[:len(attempts)-1]...)
 
-		response, executeErr := executeRequest(client, currentConfiguredRequest, executionContext.Session)
+		if requestResponse.Response.StatusCode == http.StatusUnauthorized && mergedProfiles.OnUnauthorized != "" {
+			result = append(result, *requestResponse)
+			if currentConfiguredRequest.SingleUseBody {
+				return result, singleUseBodyError("replay the request after logging in")
+			}
 
-		if executeErr != nil {
-			return result, executeErr
-		}
+			loginResponse, loginErr := executeOnUnauthorized(budget, client, mergedProfiles, executionContext, initialVariables)
+			if loginErr != nil {
+				return result, budgetError(budget, executionContext.MaxTime, loginErr)
+			}
+			result = append(result, *loginResponse)
 
-		requestResponse := ExecutedRequestResponse{
-			Request:  currentConfiguredRequest,
-			Response: *response,
+			// Reload the session to pick up the variables set by the login request
+			executionContext.Session, sessionErr = loadSessionForRequest(variables.ReplaceVariables(currentConfiguredRequest.URL, initialVariables))
+			if sessionErr != nil {
+				return result, sessionErr
+			}
+
+			// Replay the original request only once
+			attempts, executeErr = executeWithRetries(budget, client, currentConfiguredRequest, mergedProfiles, executionContext)
+			if executeErr != nil {
+				return append(result, attempts...), budgetError(budget, executionContext.MaxTime, executeErr)
+			}
+
+			requestResponse = &attempts[len(attempts)-1]
+			result = append(result, attempts[:len(attempts)-1]...)
 		}
-		result = append(result, requestResponse)
 
-		sourceCode := currentConfiguredRequest.PostProcessCode
+		result = append(result, *requestResponse)
+		response := &requestResponse.Response
+
+		sourceCode := requestResponse.Request.PostProcessCode
 		postProcessResult, postProcessError := PostProcess(sourceCode, &executionContext, result, executeErr)
 		result[len(result)-1].PostProcessOutput = postProcessResult.Output
 		if postProcessError != nil {
@@ -76,19 +149,24 @@ func ExecuteRequestLoop(executionContext ExecutionContext) ([]ExecutedRequestRes
 			requestsToExecute = append(requestsToExecute, postProcessResult.Requests...)
 		}
 
-		if executeErr != nil {
-			return result, executeErr
-		}
-
 		if shouldRedirect(response.StatusCode) && executionContext.FollowLocation == true {
-			redirectCount++
-
-			if redirectCount > executionContext.MaxRedirect {
-				return result, fmt.Errorf("Max number of redirects reached: %d/%d", redirectCount, executionContext.MaxRedirect)
+			// Redirects are built from the request before it was authorized, so signatures and tokens
+			// are calculated again for the new location, and only if it's on the same host
+			redirectRequest, redirectErr := buildRedirect(currentConfiguredRequest, requestResponse.Request, response)
+			if redirectErr != nil {
+				return result, redirectErr
 			}
 
-			redirectRequest := buildRedirect(&currentConfiguredRequest, response)
-			requestsToExecute = append(requestsToExecute, *redirectRequest)
+			// Without a location there's nowhere to go, same as not following redirects
+			if redirectRequest != nil {
+				redirectCount++
+
+				if redirectCount > executionContext.MaxRedirect {
+					return result, fmt.Errorf("Max number of redirects reached: %d/%d", redirectCount, executionContext.MaxRedirect)
+				}
+
+				requestsToExecute = append(requestsToExecute, *redirectRequest)
+			}
 		}
 
 		// If nothing else to execute, break
@@ -100,20 +178,118 @@ func ExecuteRequestLoop(executionContext ExecutionContext) ([]ExecutedRequestRes
 	return result, nil
 }
 
-func buildRedirect(req *Request, response *Response) *Request {
+// buildRedirect creates the request to follow the location in a redirect response, copying what was
+// configured in the request. The location is resolved against the URL of the executed request.
+// Returns nil if the response has no location to follow.
+func buildRedirect(req Request, executed Request, response *Response) (*Request, error) {
 	locationValues := response.Headers["Location"]
-	if len(locationValues) > 0 && locationValues[0] != "" {
-		location := locationValues[0]
-		if !strings.HasPrefix(location, "http") {
-			parsedURL, _ := url.Parse(req.URL)
-			location = parsedURL.Scheme + "://" + parsedURL.Host + location
-		}
-		return &Request{
-			URL: location,
+	if len(locationValues) == 0 || locationValues[0] == "" {
+		return nil, nil
+	}
+
+	currentURL, currentURLErr := buildURL(executed)
+	if currentURLErr != nil {
+		return nil, currentURLErr
+	}
+
+	location, locationErr := url.Parse(locationValues[0])
+	if locationErr != nil {
+		return nil, fmt.Errorf("Invalid location in redirect response: %s", locationErr)
+	}
+	redirectURL := currentURL.ResolveReference(location)
+
+	redirect := &Request{
+		AllowInsecure: req.AllowInsecure,
+		Auth:          req.Auth,
+		Body:          req.Body,
+		BodyFile:      req.BodyFile,
+		Headers:       make(map[string][]string),
+		HTTPVersion:   req.HTTPVersion,
+		Method:        req.Method,
+		Multipart:     req.Multipart,
+		Proxy:         req.Proxy,
+		Retry:         req.Retry,
+		Timeouts:      req.Timeouts,
+		TLS:           req.TLS,
+		UnixSocket:    req.UnixSocket,
+		URL:           redirectURL.String(),
+	}
+
+	for name, values := range req.Headers {
+		redirect.Headers[name] = values
+	}
+
+	if changesToGet(response.StatusCode, req.Method) {
+		redirect.Method = http.MethodGet
+		redirect.Body = nil
+		redirect.BodyFile = ""
+		redirect.Multipart = nil
+		deleteHeaders(redirect.Headers, "Content-Length", "Content-Type")
+	} else if req.SingleUseBody {
+		return nil, singleUseBodyError("follow the redirect")
+	}
+
+	// Credentials must not be sent to a different host
+	if !strings.EqualFold(currentURL.Host, redirectURL.Host) {
+		redirect.Auth = authorization.Authorization{}
+		deleteHeaders(redirect.Headers, "Authorization", "Cookie", req.Auth.Header, req.Auth.TimestampHeader)
+	}
+
+	return redirect, nil
+}
+
+// changesToGet returns true if the redirect must be followed with a GET without body. 303 always
+// changes the method and, like browsers and curl, 301 and 302 change it for POST.
+func changesToGet(statusCode int, method string) bool {
+	switch statusCode {
+	case http.StatusSeeOther:
+		return method != http.MethodGet && method != http.MethodHead
+	case http.StatusMovedPermanently, http.StatusFound:
+		return method == http.MethodPost
+	}
+	return false
+}
+
+func deleteHeaders(headers map[string][]string, names ...string) {
+	for name := range headers {
+		for _, toDelete := range names {
+			if toDelete != "" && strings.EqualFold(strings.TrimSpace(name), toDelete) {
+				delete(headers, name)
+			}
 		}
 	}
+}
+
+// executeOnUnauthorized executes the request configured to be called when a response is 401.
+// The post process script runs in the session of the unauthorized request, so that variables set
+// by it are available when the original request is replayed.
+func executeOnUnauthorized(budget context.Context, client *http.Client, mergedProfiles profile.Options, executionContext ExecutionContext, initialVariables map[string]string) (*ExecutedRequestResponse, error) {
+	loginRequest, configureErr := ConfigureRequestSimple(Request{}, &mergedProfiles, mergedProfiles.OnUnauthorized)
+	if configureErr != nil {
+		return nil, configureErr
+	}
+
+	loginContext := executionContext
+
+	var sessionErr error
+	loginContext.Session, sessionErr = loadSessionForRequest(variables.ReplaceVariables(loginRequest.URL, initialVariables))
+	if sessionErr != nil {
+		return nil, sessionErr
+	}
 
-	return nil
+	loginResponse, executeErr := prepareAndExecute(budget, client, *loginRequest, mergedProfiles, loginContext)
+	if executeErr != nil {
+		return nil, executeErr
+	}
+
+	sourceCode := loginRequest.PostProcessCode
+	postProcessResult, postProcessError := PostProcess(sourceCode, &executionContext, []ExecutedRequestResponse{*loginResponse}, nil)
+	loginResponse.PostProcessOutput = postProcessResult.Output
+	if postProcessError != nil {
+		loginResponse.PostProcessError = fmt.Sprintf("%s @ %s", postProcessError.Error(), sourceCode.SourceFilePath)
+	}
+
+	return loginResponse, nil
 }
 
 func createHTTPClient() *http.Client {
@@ -125,25 +301,34 @@ func createHTTPClient() *http.Client {
 	}
 }
 
-func executeRequest(client *http.Client, configuredRequest Request, currentSession *session.Session) (*Response, error) {
+func executeRequest(budget context.Context, client *http.Client, configuredRequest Request, executionContext ExecutionContext) (*Response, error) {
 	httpRequest, httpRequestErr := BuildRequest(configuredRequest)
 	if httpRequestErr != nil {
 		return nil, httpRequestErr
 	}
 
-	httpResponse, httpResponseErr := client.Do(httpRequest)
-	if httpResponseErr != nil {
-		return nil, httpResponseErr
+	if (configuredRequest.BodyFile != "" || len(configuredRequest.Multipart) > 0) && executionContext.OnProgress != nil {
+		httpRequest.Body = upload.WithProgress(httpRequest.Body, configuredRequest.BodyFile, httpRequest.ContentLength, executionContext.OnProgress)
 	}
 
-	for _, cookie := range httpResponse.Cookies() {
-		session.SetCookie(currentSession.Host, cookie)
+	// The request timeout includes reading the body, so the context can only be canceled after that
+	ctx := budget
+	if configuredRequest.Timeouts.Request > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(budget, configuredRequest.Timeouts.Request)
+		defer cancel()
 	}
 
-	bodyBytes, readErr := ioutil.ReadAll(httpResponse.Body)
+	tracer := newTimingsTracer()
+	httpResponse, httpResponseErr := client.Do(httpRequest.WithContext(tracer.withContext(ctx)))
+	if httpResponseErr != nil {
+		return nil, describeRequestError(budget, ctx, configuredRequest, httpResponseErr)
+	}
+	defer httpResponse.Body.Close()
+	tracer.gotResponse()
 
-	if readErr != nil {
-		return nil, readErr
+	for _, cookie := range httpResponse.Cookies() {
+		session.SetCookie(executionContext.Session.Host, cookie)
 	}
 
 	headers := make(map[string][]string)
@@ -151,15 +336,265 @@ func executeRequest(client *http.Client, configuredRequest Request, currentSessi
 		headers[k] = append(headers[k], vs...)
 	}
 
-	return &Response{
+	response := &Response{
 		StatusCode: httpResponse.StatusCode,
 		Status:     httpResponse.Status,
 		Headers:    headers,
-		Body:       string(bodyBytes),
 		Protocol:   fmt.Sprintf("%d.%d", httpResponse.ProtoMajor, httpResponse.ProtoMinor),
+	}
+
+	// Bodies of redirects that will be followed are not what the user wants to save
+	if executionContext.Download.Enabled() && !willFollowRedirect(executionContext, response) {
+		fileName, fileNameErr := executionContext.Download.FileName(httpResponse.Header.Get("Content-Disposition"), httpRequest.URL.String())
+		if fileNameErr != nil {
+			return nil, fileNameErr
+		}
+
+		var saveErr error
+		response.Download, saveErr = download.Save(httpResponse.Body, fileName, executionContext.Download.Overwrite(), httpResponse.ContentLength, executionContext.OnProgress)
+		if saveErr != nil {
+			return nil, describeRequestError(budget, ctx, configuredRequest, saveErr)
+		}
+		response.Timings = tracer.timings(time.Now())
+		return response, nil
+	}
+
+	bodyBytes, readErr := ioutil.ReadAll(httpResponse.Body)
+
+	if readErr != nil {
+		return nil, describeRequestError(budget, ctx, configuredRequest, readErr)
+	}
+	response.Body = bodyBytes
+	response.Timings = tracer.timings(time.Now())
+
+	return response, nil
+}
+
+// budgetError returns a timeout error if the budget for the execution loop ran out, which is what
+// caused the error, or the error as it is otherwise
+func budgetError(budget context.Context, maxTime time.Duration, err error) error {
+	if budget.Err() == context.DeadlineExceeded {
+		return timeout.Error{Kind: timeout.MaxTimeKind, Timeout: maxTime}
+	}
+	return err
+}
+
+// describeRequestError converts errors caused by the timeouts configured in the request into timeout
+// errors. Errors caused by the budget are left for the execution loop to describe.
+func describeRequestError(budget context.Context, ctx context.Context, configuredRequest Request, err error) error {
+	if budget.Err() != nil {
+		return err
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		return timeout.Error{Kind: timeout.RequestKind, Timeout: configuredRequest.Timeouts.Request}
+	}
+
+	return configuredRequest.Timeouts.DescribeError(tlsconfig.DescribeError(protocol.DescribeError(configuredRequest.HTTPVersion, err)))
+}
+
+// prepareAndExecute replaces variables, applies authorization and then executes the request
+func prepareAndExecute(budget context.Context, client *http.Client, unprocessedRequest Request, mergedProfiles profile.Options, executionContext ExecutionContext) (*ExecutedRequestResponse, error) {
+	configuredRequest, replaceVariablesError := replaceRequestVariables(unprocessedRequest, mergedProfiles, executionContext)
+	if replaceVariablesError != nil {
+		return nil, replaceVariablesError
+	}
+
+	auth := configuredRequest.Auth
+	if auth.AuthorizationType == "" {
+		var netrcErr error
+		if auth, netrcErr = netrcAuthorization(configuredRequest, executionContext.Netrc); netrcErr != nil {
+			return nil, netrcErr
+		}
+	}
+
+	auth, commandsErr := auth.ResolveCommands()
+	if commandsErr != nil {
+		return nil, commandsErr
+	}
+
+	transport, transportErr := newTransport(configuredRequest, executionContext)
+	if transportErr != nil {
+		return nil, transportErr
+	}
+
+	// QUIC runs over UDP, which HTTP and SOCKS5 proxies can't forward
+	if configuredRequest.HTTPVersion == protocol.HTTP3 && configuredRequest.Proxy.URL != "" {
+		return nil, protocol.Error{Err: errors.New("requests can't be sent through a proxy"), Version: protocol.HTTP3}
+	}
+
+	// Tokens are requested with the same TLS, proxy and timeouts as the request. The transport isn't
+	// cloned because cloning sets the HTTP/2 defaults before the request's protocol is configured.
+	tokenTransport, _ := newTransport(configuredRequest, executionContext)
+	timeouts := configuredRequest.Timeouts
+	tokenClient := newTokenClient(budget, timeouts, tokenTransport)
+	defer tokenClient.CloseIdleConnections()
+
+	configuredRequest, authError := applyAuthorization(configuredRequest, auth, executionContext.ProfileNames, tokenClient)
+	if authError != nil {
+		return nil, authError
+	}
+
+	// Requests keep their URL, so the Host header and the path are sent as configured, but they go
+	// straight to the socket, without proxies
+	if unixSocket := configuredRequest.UnixSocket; unixSocket != "" {
+		if configuredRequest.HTTPVersion == protocol.HTTP3 {
+			return nil, protocol.Error{Err: errors.New("requests can't be sent to a Unix socket"), Version: protocol.HTTP3}
+		}
+
+		dialer := &net.Dialer{Timeout: timeouts.Connect}
+		transport.DialContext = func(ctx context.Context, _ string, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", unixSocket)
+		}
+		transport.Proxy = nil
+	}
+
+	roundTripper, protocolErr := protocol.RoundTripper(configuredRequest.HTTPVersion, transport, timeouts.Connect)
+	if protocolErr != nil {
+		return nil, protocolErr
+	}
+
+	// A new transport is created for each request, its connections can't be reused after it
+	client.Transport = roundTripper
+	defer client.CloseIdleConnections()
+	if closer, isCloser := roundTripper.(io.Closer); isCloser {
+		defer closer.Close()
+	}
+
+	// The request is returned with the error so that the attempt can be retried
+	response, executeErr := executeRequest(budget, client, configuredRequest, executionContext)
+	if executeErr != nil {
+		return &ExecutedRequestResponse{Request: configuredRequest}, executeErr
+	}
+
+	// Challenge/response authorizations send the request again after receiving the challenge
+	challengedRequest, challenged, challengeErr := respondToChallenge(configuredRequest, response, auth)
+	if challengeErr != nil {
+		return nil, challengeErr
+	}
+
+	if challenged {
+		if configuredRequest.SingleUseBody {
+			return nil, singleUseBodyError("respond to the authorization challenge")
+		}
+
+		configuredRequest = challengedRequest
+		response, executeErr = executeRequest(budget, client, configuredRequest, executionContext)
+		if executeErr != nil {
+			return &ExecutedRequestResponse{Request: configuredRequest}, executeErr
+		}
+	}
+
+	return &ExecutedRequestResponse{
+		Request:  configuredRequest,
+		Response: *response,
 	}, nil
 }
 
+// singleUseBodyError is returned when a request needs to be sent again, but its body was already
+// read from a pipe
+func singleUseBodyError(action string) error {
+	return fmt.Errorf("Can't %s, the body was read from the standard input and can only be sent once", action)
+}
+
+// newTransport creates the transport for the request with its TLS configuration, proxy and timeouts
+func newTransport(configuredRequest Request, executionContext ExecutionContext) (*http.Transport, error) {
+	tlsConfig, tlsErr := configuredRequest.TLS.ToConfig(executionContext.AllowInsecure || configuredRequest.AllowInsecure)
+	if tlsErr != nil {
+		return nil, tlsErr
+	}
+
+	// The daemon doesn't have the environment from where the request was made, so it can't be used
+	proxyFunc, proxyErr := configuredRequest.Proxy.ProxyFunc(executionContext.ProxyEnvironment)
+	if proxyErr != nil {
+		return nil, proxyErr
+	}
+
+	timeouts := configuredRequest.Timeouts
+	return &http.Transport{
+		DialContext:           (&net.Dialer{Timeout: timeouts.Connect}).DialContext,
+		Proxy:                 proxyFunc,
+		ResponseHeaderTimeout: timeouts.ResponseHeader,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   timeouts.TLSHandshake,
+	}, nil
+}
+
+// NewLoginClient creates the client to request OAuth2 tokens when the user logs in, with the same
+// TLS configuration, proxy and timeouts as the request that needs the tokens
+func NewLoginClient(configuredRequest Request, executionContext ExecutionContext) (*http.Client, error) {
+	transport, transportErr := newTransport(configuredRequest, executionContext)
+	if transportErr != nil {
+		return nil, transportErr
+	}
+
+	return newTokenClient(context.Background(), configuredRequest.Timeouts, transport), nil
+}
+
+// newTokenClient creates the client used to request OAuth2 tokens while authorizing a request. The
+// token endpoint gets the request timeout, or a default one, limited by what is left of the budget.
+func newTokenClient(budget context.Context, timeouts timeout.Options, transport *http.Transport) *http.Client {
+	tokenTimeout := defaultTokenTimeout
+	if timeouts.Request > 0 {
+		tokenTimeout = timeouts.Request
+	}
+
+	if deadline, hasDeadline := budget.Deadline(); hasDeadline && time.Until(deadline) < tokenTimeout {
+		tokenTimeout = time.Until(deadline)
+	}
+
+	return &http.Client{Timeout: tokenTimeout, Transport: transport}
+}
+
+// executeWithRetries executes the request and retries it as configured in its retry policy. Returns
+// all attempts, the last one being the response to process. If the last attempt fails, only the
+// attempts that were retried are returned with the error.
+func executeWithRetries(budget context.Context, client *http.Client, unprocessedRequest Request, mergedProfiles profile.Options, executionContext ExecutionContext) ([]ExecutedRequestResponse, error) {
+	attempts := make([]ExecutedRequestResponse, 0)
+	for retryNumber := 1; ; retryNumber++ {
+		attempt, executeErr := prepareAndExecute(budget, client, unprocessedRequest, mergedProfiles, executionContext)
+		if attempt == nil {
+			return attempts, executeErr
+		}
+
+		retryOptions := attempt.Request.Retry
+		reason, shouldRetry := retryOptions.ShouldRetry(attempt.Request.Method, attempt.Response.StatusCode, executeErr)
+		if !shouldRetry || retryNumber > retryOptions.Attempts {
+			if executeErr != nil {
+				return attempts, executeErr
+			}
+			return append(attempts, *attempt), nil
+		}
+
+		if attempt.Request.SingleUseBody {
+			if executeErr != nil {
+				return attempts, executeErr
+			}
+			return append(attempts, *attempt), singleUseBodyError("retry the request")
+		}
+
+		var retryAfter string
+		if values := attempt.Response.Headers["Retry-After"]; len(values) > 0 {
+			retryAfter = values[0]
+		}
+
+		// Waiting can't take longer than the budget has left
+		deadline, _ := budget.Deadline()
+		attempt.RetryDelay = retryOptions.Backoff(retryNumber, retryAfter, time.Now(), deadline)
+		attempt.RetryReason = reason
+		if executeErr != nil {
+			attempt.Error = executeErr.Error()
+		}
+		attempts = append(attempts, *attempt)
+
+		select {
+		case <-time.After(attempt.RetryDelay):
+		case <-budget.Done():
+			return attempts, budget.Err()
+		}
+	}
+}
+
 func loadSessionForRequest(requestURL string) (*session.Session, error) {
 	parsedURL, parseURLErr := url.Parse(requestURL)
 	if parseURLErr != nil {
@@ -169,8 +604,17 @@ func loadSessionForRequest(requestURL string) (*session.Session, error) {
 	return session.Get(parsedURL.Hostname()), nil
 }
 
+func willFollowRedirect(executionContext ExecutionContext, response *Response) bool {
+	return executionContext.FollowLocation &&
+		shouldRedirect(response.StatusCode) &&
+		len(response.Headers["Location"]) > 0 &&
+		response.Headers["Location"][0] != ""
+}
+
 func shouldRedirect(statusCode int) bool {
 	return statusCode == http.StatusMovedPermanently ||
 		statusCode == http.StatusFound ||
-		statusCode == http.StatusSeeOther
+		statusCode == http.StatusSeeOther ||
+		statusCode == http.StatusTemporaryRedirect ||
+		statusCode == http.StatusPermanentRedirect
 }
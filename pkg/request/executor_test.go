@@ -1,12 +1,35 @@
 package request
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/visola/go-http-cli/pkg/download"
+	"github.com/visola/go-http-cli/pkg/profile"
+	"github.com/visola/go-http-cli/pkg/progress"
+	"github.com/visola/go-http-cli/pkg/protocol"
+	"github.com/visola/go-http-cli/pkg/proxy"
+	"github.com/visola/go-http-cli/pkg/retry"
+	"github.com/visola/go-http-cli/pkg/timeout"
+	"github.com/visola/go-http-cli/pkg/tlsconfig"
 )
 
 func TestExecuteRequest(t *testing.T) {
@@ -15,6 +38,69 @@ func TestExecuteRequest(t *testing.T) {
 	t.Run("Should bail if max number of redirects happens", testMaxRedirects)
 }
 
+func TestRedirects(t *testing.T) {
+	t.Run("Keeps method, body and headers for 307 and 308", testKeepsMethodAndBodyForTemporaryAndPermanentRedirects)
+	t.Run("Changes to GET for 303 and POST in 301 and 302", testChangesToGetForSeeOther)
+	t.Run("Drops authorization on cross host redirect", testDropsAuthorizationOnCrossHostRedirect)
+	t.Run("Signs again only for the same host", testSignsAgainOnlyForSameHost)
+	t.Run("Resolves location against current URL", testResolvesLocation)
+	t.Run("Stops without location", testStopsWithoutLocation)
+}
+
+func TestTimeouts(t *testing.T) {
+	t.Run("Times out waiting for headers with timeout from profile", testTimesOutWaitingForHeaders)
+	t.Run("Named request overrides request timeout", testNamedRequestOverridesRequestTimeout)
+	t.Run("Times out when all requests take longer than max time", testTimesOutAfterMaxTime)
+}
+
+func TestRetries(t *testing.T) {
+	t.Run("Retries with policy from profile until it succeeds", testRetriesUntilSuccess)
+	t.Run("Post processes the last attempt", testPostProcessesLastAttempt)
+	t.Run("Doesn't retry POST unless allowed", testDoesNotRetryPostUnlessAllowed)
+	t.Run("Returns attempts when connection fails", testReturnsAttemptsWhenConnectionFails)
+}
+
+func TestProxies(t *testing.T) {
+	t.Run("Sends request through HTTP proxy from profile", testSendsRequestThroughHTTPProxy)
+	t.Run("Tunnels HTTPS through HTTP proxy", testTunnelsHTTPSThroughHTTPProxy)
+	t.Run("Sends request through SOCKS5 proxy", testSendsRequestThroughSOCKS5Proxy)
+}
+
+func TestProtocols(t *testing.T) {
+	t.Run("Negotiates HTTP/2 with TLS options", testNegotiatesHTTP2WithTLSOptions)
+	t.Run("Uses HTTP/2 with prior knowledge from profile", testUsesHTTP2WithPriorKnowledgeFromProfile)
+	t.Run("Named request overrides HTTP version", testNamedRequestOverridesHTTPVersion)
+	t.Run("Doesn't send HTTP/3 through proxy", testDoesNotSendHTTP3ThroughProxy)
+}
+
+func TestUnixSockets(t *testing.T) {
+	t.Run("Sends request to Unix socket keeping host and path", testSendsRequestToUnixSocket)
+	t.Run("Uses Unix socket from profile relative to profiles dir", testUsesUnixSocketFromProfile)
+}
+
+func TestTimings(t *testing.T) {
+	t.Run("Records time to first byte and transfer", testRecordsTimeToFirstByteAndTransfer)
+	t.Run("Records connection and TLS handshake", testRecordsConnectionAndTLSHandshake)
+}
+
+func TestDownloads(t *testing.T) {
+	t.Run("Streams body to output file", testStreamsBodyToOutputFile)
+	t.Run("Saves only the body after following redirects", testSavesOnlyBodyAfterRedirects)
+}
+
+func TestUploads(t *testing.T) {
+	t.Run("Streams file with Content-Length", testStreamsFileWithContentLength)
+	t.Run("Sends file again when following redirects", testSendsFileAgainWhenFollowingRedirects)
+	t.Run("Doesn't send single use body again", testDoesNotSendSingleUseBodyAgain)
+	t.Run("Streams multipart form from named request", testStreamsMultipartFormFromNamedRequest)
+}
+
+func TestClientCertificate(t *testing.T) {
+	t.Run("Sends client certificate from profile", testSendsClientCertificateFromProfile)
+	t.Run("Named request overrides client certificate", testNamedRequestOverridesClientCertificate)
+	t.Run("Sends client certificate to token endpoint", testSendsClientCertificateToTokenEndpoint)
+}
+
 func testBasicGet(t *testing.T) {
 	request := Request{
 		Method: http.MethodGet,
@@ -71,3 +157,908 @@ func testMaxRedirects(t *testing.T) {
 	// It should still return the requests that were executed and their responses
 	assert.Equal(t, 11, len(executedRequestResponses), "Should have executed 11 requests")
 }
+
+func testSendsClientCertificateFromProfile(t *testing.T) {
+	server := createMutualTLSServer()
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	tlsconfig.CreateTestCertificate(profilesDir, "profile-client")
+	profile.CreateTestProfile("mtls", "tls:\n  cert: profile-client.crt\n  key: profile-client.key\n", profilesDir)
+
+	executedRequestResponses, err := executeWithProfile(t, "mtls", "", server.URL)
+	require.Nil(t, err, "Should execute request")
+	assert.Equal(t, "profile-client", string(executedRequestResponses[0].Response.Body), "Should send certificate from profile")
+}
+
+func testNamedRequestOverridesClientCertificate(t *testing.T) {
+	server := createMutualTLSServer()
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	tlsconfig.CreateTestCertificate(profilesDir, "profile-client")
+	tlsconfig.CreateTestCertificate(profilesDir, "request-client")
+	profile.CreateTestProfile("mtls", `tls:
+  cert: profile-client.crt
+  key: profile-client.key
+requests:
+  other:
+    tls:
+      cert: request-client.crt
+      key: request-client.key
+`, profilesDir)
+
+	executedRequestResponses, err := executeWithProfile(t, "mtls", "other", server.URL)
+	require.Nil(t, err, "Should execute request")
+	assert.Equal(t, "request-client", string(executedRequestResponses[0].Response.Body), "Should send certificate from named request")
+}
+
+func testSendsClientCertificateToTokenEndpoint(t *testing.T) {
+	server := createMutualTLSServer()
+	defer server.Close()
+
+	tokenServer := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"access_token":"%s","token_type":"bearer"}`, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	tokenServer.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	tokenServer.StartTLS()
+	defer tokenServer.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	tlsconfig.CreateTestCertificate(profilesDir, "profile-client")
+	profile.CreateTestProfile("mtls", fmt.Sprintf(`tls:
+  cert: profile-client.crt
+  key: profile-client.key
+auth:
+  type: oauth2-client-credentials
+  tokenURL: %s/token
+  clientId: my-client
+  clientSecret: my-secret
+`, tokenServer.URL), profilesDir)
+
+	executedRequestResponses, err := executeWithProfile(t, "mtls", "", server.URL)
+	require.Nil(t, err, "Should execute request")
+	assert.Equal(t, "Bearer profile-client", executedRequestResponses[0].Request.Headers["Authorization"][0], "Should request token with the client certificate")
+}
+
+func testKeepsMethodAndBodyForTemporaryAndPermanentRedirects(t *testing.T) {
+	server := createRedirectServer()
+	defer server.Close()
+
+	for _, statusCode := range []int{http.StatusTemporaryRedirect, http.StatusPermanentRedirect} {
+		executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+			FollowLocation: true,
+			MaxRedirect:    10,
+			Request: Request{
+				Body:    []byte(`{"id":1}`),
+				Headers: map[string][]string{"Content-Type": {"application/json"}, "X-Custom": {"value"}},
+				Method:  http.MethodPut,
+				URL:     fmt.Sprintf("%s/redirect?status=%d&to=/echo", server.URL, statusCode),
+			},
+		})
+
+		require.Nil(t, err, "Should execute request")
+		require.Equal(t, 2, len(executedRequestResponses), "Should record each hop")
+		assert.Equal(t, statusCode, executedRequestResponses[0].Response.StatusCode, "Should redirect first")
+		assert.Equal(t, `PUT application/json value {"id":1}`, string(executedRequestResponses[1].Response.Body), "Should keep method, headers and body")
+	}
+}
+
+func testChangesToGetForSeeOther(t *testing.T) {
+	server := createRedirectServer()
+	defer server.Close()
+
+	tests := []struct {
+		expected   string
+		method     string
+		statusCode int
+	}{
+		// Custom headers are kept, but body and its content type are dropped
+		{"GET  value ", http.MethodPut, http.StatusSeeOther},
+		{"GET  value ", http.MethodPost, http.StatusFound},
+		{"GET  value ", http.MethodPost, http.StatusMovedPermanently},
+		{`PUT application/json value {"id":1}`, http.MethodPut, http.StatusFound},
+	}
+
+	for _, test := range tests {
+		executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+			FollowLocation: true,
+			MaxRedirect:    10,
+			Request: Request{
+				Body:    []byte(`{"id":1}`),
+				Headers: map[string][]string{"Content-Type": {"application/json"}, "X-Custom": {"value"}},
+				Method:  test.method,
+				URL:     fmt.Sprintf("%s/redirect?status=%d&to=/echo", server.URL, test.statusCode),
+			},
+		})
+
+		require.Nil(t, err, "Should execute request")
+		require.Equal(t, 2, len(executedRequestResponses), "Should record each hop")
+		assert.Equal(t, test.expected, string(executedRequestResponses[1].Response.Body), "Should follow %d for %s correctly", test.statusCode, test.method)
+	}
+}
+
+func testDropsAuthorizationOnCrossHostRedirect(t *testing.T) {
+	otherServer := createRedirectServer()
+	defer otherServer.Close()
+
+	server := createRedirectServer()
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("redirect-auth", "auth:\n  type: bearer\n  token: my-token\n", profilesDir)
+
+	executeRedirect := func(to string) []ExecutedRequestResponse {
+		executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+			FollowLocation: true,
+			MaxRedirect:    10,
+			ProfileNames:   []string{"redirect-auth"},
+			Request: Request{
+				Headers: map[string][]string{"Cookie": {"session=abc"}},
+				URL:     fmt.Sprintf("%s/redirect?status=%d&to=%s", server.URL, http.StatusTemporaryRedirect, to),
+			},
+		})
+		require.Nil(t, err, "Should execute request")
+		require.Equal(t, 2, len(executedRequestResponses), "Should record each hop")
+		return executedRequestResponses
+	}
+
+	sameHost := executeRedirect("/auth")
+	assert.Equal(t, "Bearer my-token", string(sameHost[1].Response.Body), "Should keep authorization for the same host")
+
+	otherHost := executeRedirect(url.QueryEscape(otherServer.URL + "/auth"))
+	assert.Equal(t, "", string(otherHost[1].Response.Body), "Should drop authorization for other hosts")
+	assert.Empty(t, otherHost[1].Request.Headers["Cookie"], "Should drop cookie header for other hosts")
+}
+
+func testSignsAgainOnlyForSameHost(t *testing.T) {
+	signedHeaders := []string{"Authorization", "X-Amz-Content-Sha256", "X-Amz-Date", "X-Amz-Security-Token"}
+
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header)
+		if location := r.URL.Query().Get("to"); location != "" {
+			w.Header().Set("Location", location)
+			w.WriteHeader(http.StatusTemporaryRedirect)
+		}
+	}))
+	defer server.Close()
+
+	// Same server in a different host
+	otherHost := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("redirect-sigv4", `auth:
+  type: aws-sigv4
+  accessKeyId: my-key
+  secretAccessKey: my-secret
+  sessionToken: my-session-token
+  region: us-east-1
+  service: s3
+`, profilesDir)
+
+	executeRedirect := func(to string) {
+		received = nil
+		executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+			FollowLocation: true,
+			MaxRedirect:    10,
+			ProfileNames:   []string{"redirect-sigv4"},
+			Request:        Request{URL: server.URL + "/?to=" + url.QueryEscape(to)},
+		})
+		require.Nil(t, err, "Should execute request")
+		require.Equal(t, 2, len(executedRequestResponses), "Should record each hop")
+		require.Equal(t, 2, len(received), "Should receive each hop")
+	}
+
+	executeRedirect("/next")
+	assert.Equal(t, "my-session-token", received[1].Get("X-Amz-Security-Token"), "Should send session token to the same host")
+	assert.Contains(t, received[1].Get("Authorization"), "SignedHeaders=", "Should sign again for the same host")
+	assert.NotEqual(t, received[0].Get("Authorization"), received[1].Get("Authorization"), "Should sign the new location")
+
+	executeRedirect(otherHost + "/next")
+	for _, header := range signedHeaders {
+		assert.Empty(t, received[1].Get(header), "Should not send %s to other hosts", header)
+	}
+}
+
+func testResolvesLocation(t *testing.T) {
+	tests := map[string]string{
+		"/absolute/path":               "https://example.com/absolute/path",
+		"relative":                     "https://example.com/some/relative",
+		"?page=2":                      "https://example.com/some/path?page=2",
+		"//other.example.com/path":     "https://other.example.com/path",
+		"http://other.example.com/abc": "http://other.example.com/abc",
+	}
+
+	for location, expected := range tests {
+		executed := Request{URL: "https://example.com/some/path", QueryParams: map[string][]string{"a": {"1"}}}
+		redirect, err := buildRedirect(
+			executed,
+			executed,
+			&Response{StatusCode: http.StatusFound, Headers: map[string][]string{"Location": {location}}},
+		)
+
+		require.Nil(t, err, "Should build redirect")
+		assert.Equal(t, expected, redirect.URL, "Should resolve %s", location)
+	}
+}
+
+func testStopsWithoutLocation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer server.Close()
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		FollowLocation: true,
+		MaxRedirect:    10,
+		Request:        Request{URL: server.URL},
+	})
+
+	assert.Nil(t, err, "Should execute request")
+	assert.Equal(t, 1, len(executedRequestResponses), "Should not follow redirect without location")
+}
+
+func testTimesOutWaitingForHeaders(t *testing.T) {
+	server := createSlowServer(200 * time.Millisecond)
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("slow", "timeouts:\n  responseHeader: 50ms\n", profilesDir)
+
+	_, err := executeWithProfile(t, "slow", "", server.URL+"/slow-headers")
+	assert.Equal(t, timeout.Error{Kind: timeout.ResponseHeaderKind, Timeout: 50 * time.Millisecond}, err, "Should time out waiting for headers")
+}
+
+func testNamedRequestOverridesRequestTimeout(t *testing.T) {
+	server := createSlowServer(200 * time.Millisecond)
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("slow", `timeouts:
+  request: 1s
+requests:
+  impatient:
+    timeouts:
+      request: 0.05
+`, profilesDir)
+
+	executedRequestResponses, err := executeWithProfile(t, "slow", "", server.URL+"/slow-body")
+	require.Nil(t, err, "Should execute request within the profile timeout")
+	assert.Equal(t, "done", string(executedRequestResponses[0].Response.Body), "Should read the body")
+
+	_, err = executeWithProfile(t, "slow", "impatient", server.URL+"/slow-body")
+	assert.Equal(t, timeout.Error{Kind: timeout.RequestKind, Timeout: 50 * time.Millisecond}, err, "Should time out reading the body")
+}
+
+func testTimesOutAfterMaxTime(t *testing.T) {
+	server := createSlowServer(50 * time.Millisecond)
+	defer server.Close()
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		FollowLocation: true,
+		MaxRedirect:    100,
+		MaxTime:        120 * time.Millisecond,
+		Request:        Request{URL: server.URL + "/slow-redirect"},
+	})
+
+	assert.Equal(t, timeout.Error{Kind: timeout.MaxTimeKind, Timeout: 120 * time.Millisecond}, err, "Should stop when the budget runs out")
+	assert.True(t, len(executedRequestResponses) >= 1, "Should return the requests executed before the budget ran out")
+	assert.True(t, len(executedRequestResponses) < 3, "Should not execute requests after the budget ran out")
+}
+
+func testRetriesUntilSuccess(t *testing.T) {
+	server := createFlakyServer(2)
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("flaky", "retry:\n  attempts: 3\n  delay: 1ms\n  on: [503]\n", profilesDir)
+
+	executedRequestResponses, err := executeWithProfile(t, "flaky", "", server.URL)
+	require.Nil(t, err, "Should execute request")
+	require.Equal(t, 3, len(executedRequestResponses), "Should return all attempts")
+
+	for _, attempt := range executedRequestResponses[:2] {
+		assert.Equal(t, http.StatusServiceUnavailable, attempt.Response.StatusCode, "Should return the failed attempt")
+		assert.Equal(t, "503", attempt.RetryReason, "Should say why it retried")
+		assert.Equal(t, time.Duration(0), attempt.RetryDelay, "Should honor Retry-After")
+	}
+	assert.Equal(t, http.StatusOK, executedRequestResponses[2].Response.StatusCode, "Should succeed after retrying")
+	assert.Equal(t, "", executedRequestResponses[2].RetryReason, "Should not retry last attempt")
+}
+
+func testPostProcessesLastAttempt(t *testing.T) {
+	server := createFlakyServer(1)
+	defer server.Close()
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		Request: Request{
+			Method:          http.MethodGet,
+			PostProcessCode: PostProcessSourceCode{SourceCode: "print(response.StatusCode + ' of ' + executed.length)"},
+			Retry:           retry.Options{Attempts: 1, Delay: time.Millisecond},
+			URL:             server.URL,
+		},
+	})
+	require.Nil(t, err, "Should execute request")
+	require.Equal(t, 2, len(executedRequestResponses), "Should retry once")
+	assert.Equal(t, "200 of 2", executedRequestResponses[1].PostProcessOutput, "Should post process the response that succeeded")
+}
+
+func testDoesNotRetryPostUnlessAllowed(t *testing.T) {
+	server := createFlakyServer(1)
+	defer server.Close()
+
+	retryOptions := retry.Options{Attempts: 1, Delay: time.Millisecond}
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		Request: Request{Method: http.MethodPost, Retry: retryOptions, URL: server.URL},
+	})
+	require.Nil(t, err, "Should execute request")
+	require.Equal(t, 1, len(executedRequestResponses), "Should not retry POST")
+	assert.Equal(t, http.StatusServiceUnavailable, executedRequestResponses[0].Response.StatusCode, "Should return failed response")
+
+	allowedServer := createFlakyServer(1)
+	defer allowedServer.Close()
+
+	retryOptions.NonIdempotent = true
+	executedRequestResponses, err = ExecuteRequestLoop(ExecutionContext{
+		Request: Request{Method: http.MethodPost, Retry: retryOptions, URL: allowedServer.URL},
+	})
+	require.Nil(t, err, "Should execute request")
+	require.Equal(t, 2, len(executedRequestResponses), "Should retry POST when allowed")
+	assert.Equal(t, http.StatusOK, executedRequestResponses[1].Response.StatusCode, "Should succeed after retrying")
+}
+
+func testReturnsAttemptsWhenConnectionFails(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		Request: Request{
+			Retry: retry.Options{Attempts: 2, Delay: time.Millisecond},
+			URL:   server.URL,
+		},
+	})
+
+	assert.NotNil(t, err, "Should fail after retrying")
+	require.Equal(t, 2, len(executedRequestResponses), "Should return the attempts that were retried")
+	for _, attempt := range executedRequestResponses {
+		assert.Equal(t, retry.ConnectErrorCondition, attempt.RetryReason, "Should retry connection errors")
+		assert.NotEmpty(t, attempt.Error, "Should keep the error from the attempt")
+		assert.Equal(t, server.URL, attempt.Request.URL, "Should keep the request from the attempt")
+	}
+}
+
+func testSendsRequestThroughHTTPProxy(t *testing.T) {
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.URL, r.Header.Get("Proxy-Authorization"))
+	}))
+	defer proxyServer.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("proxied", fmt.Sprintf(`proxy:
+  url: %s
+  username: user
+  password: '{proxyPassword}'
+variables:
+  proxyPassword: secret
+`, proxyServer.URL), profilesDir)
+
+	executedRequestResponses, err := executeWithProfile(t, "proxied", "", "http://service.invalid/path")
+	require.Nil(t, err, "Should execute request")
+	assert.Equal(t, "http://service.invalid/path Basic dXNlcjpzZWNyZXQ=", string(executedRequestResponses[0].Response.Body), "Should send request to proxy with credentials")
+}
+
+func testTunnelsHTTPSThroughHTTPProxy(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "tunneled")
+	}))
+	defer server.Close()
+
+	connectedTo := ""
+	proxyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		connectedTo = r.Host
+		target, dialErr := net.Dial("tcp", r.Host)
+		if dialErr != nil {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer target.Close()
+
+		w.WriteHeader(http.StatusOK)
+		client, _, _ := w.(http.Hijacker).Hijack()
+		defer client.Close()
+
+		go io.Copy(target, client)
+		io.Copy(client, target)
+	}))
+	defer proxyServer.Close()
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		AllowInsecure:    true,
+		ProxyEnvironment: proxy.Environment{HTTPSProxy: proxyServer.URL},
+		Request:          Request{URL: server.URL},
+	})
+	require.Nil(t, err, "Should execute request")
+	assert.Equal(t, "tunneled", string(executedRequestResponses[0].Response.Body), "Should tunnel request through proxy")
+	assert.Equal(t, strings.TrimPrefix(server.URL, "https://"), connectedTo, "Should connect to the server")
+}
+
+func testSendsRequestThroughSOCKS5Proxy(t *testing.T) {
+	listener, listenErr := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, listenErr, "Should listen")
+	defer listener.Close()
+
+	go serveSOCKS5(listener)
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		Request: Request{
+			Proxy: proxy.Options{URL: "socks5h://" + listener.Addr().String()},
+			URL:   "http://service.invalid/path",
+		},
+	})
+	require.Nil(t, err, "Should execute request")
+	assert.Equal(t, "service.invalid:80 /path", string(executedRequestResponses[0].Response.Body), "Should let the proxy resolve the host")
+}
+
+// serveSOCKS5 accepts one SOCKS5 connection without authentication and, instead of connecting to
+// the requested address, responds to the HTTP request with the address and the path
+func serveSOCKS5(listener net.Listener) {
+	conn, acceptErr := listener.Accept()
+	if acceptErr != nil {
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	greeting := make([]byte, 2)
+	io.ReadFull(reader, greeting)
+	io.ReadFull(reader, make([]byte, greeting[1]))
+	conn.Write([]byte{5, 0})
+
+	// Version, command, reserved and address type, which must be a domain name
+	header := make([]byte, 4)
+	io.ReadFull(reader, header)
+	hostLength, _ := reader.ReadByte()
+	host := make([]byte, hostLength)
+	io.ReadFull(reader, host)
+	port := make([]byte, 2)
+	io.ReadFull(reader, port)
+	conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+
+	req, readErr := http.ReadRequest(reader)
+	if readErr != nil {
+		return
+	}
+
+	body := fmt.Sprintf("%s:%d %s", host, int(port[0])<<8|int(port[1]), req.URL.Path)
+	fmt.Fprintf(conn, "HTTP/1.1 200 OK\r\nContent-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+func testNegotiatesHTTP2WithTLSOptions(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		AllowInsecure: true,
+		Request:       Request{TLS: tlsconfig.Options{MinVersion: "1.2"}, URL: server.URL},
+	})
+	require.Nil(t, err, "Should execute request")
+	assert.Equal(t, "HTTP/2.0", string(executedRequestResponses[0].Response.Body), "Should send request with HTTP/2")
+	assert.Equal(t, "2.0", executedRequestResponses[0].Response.Protocol, "Should return negotiated protocol")
+}
+
+func testUsesHTTP2WithPriorKnowledgeFromProfile(t *testing.T) {
+	server := createH2CServer()
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("h2c", "httpVersion: 2-prior-knowledge\n", profilesDir)
+
+	executedRequestResponses, err := executeWithProfile(t, "h2c", "", server.URL)
+	require.Nil(t, err, "Should execute request")
+	assert.Equal(t, "HTTP/2.0", string(executedRequestResponses[0].Response.Body), "Should send request with HTTP/2 without TLS")
+	assert.Equal(t, "2.0", executedRequestResponses[0].Response.Protocol, "Should return protocol used")
+}
+
+func testNamedRequestOverridesHTTPVersion(t *testing.T) {
+	server := createH2CServer()
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("h2c", `httpVersion: 2-prior-knowledge
+requests:
+  legacy:
+    httpVersion: 1.1
+`, profilesDir)
+
+	executedRequestResponses, err := executeWithProfile(t, "h2c", "legacy", server.URL)
+	require.Nil(t, err, "Should execute request")
+	assert.Equal(t, "1.1", executedRequestResponses[0].Response.Protocol, "Should use version from named request")
+}
+
+func testDoesNotSendHTTP3ThroughProxy(t *testing.T) {
+	_, err := ExecuteRequestLoop(ExecutionContext{
+		Request: Request{HTTPVersion: protocol.HTTP3, Proxy: proxy.Options{URL: "http://proxy.invalid:3128"}, URL: "https://service.invalid"},
+	})
+	assert.IsType(t, protocol.Error{}, err, "Should not send HTTP/3 through proxy")
+}
+
+func testSendsRequestToUnixSocket(t *testing.T) {
+	dir, dirErr := ioutil.TempDir("", "socket")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	server := createUnixSocketServer(t, filepath.Join(dir, "docker.sock"))
+	defer server.Close()
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		ProxyEnvironment: proxy.Environment{HTTPProxy: "http://proxy.invalid:3128"},
+		Request: Request{
+			QueryParams: map[string][]string{"all": {"1"}},
+			UnixSocket:  filepath.Join(dir, "docker.sock"),
+			URL:         "http://docker/v1.43/containers/json",
+		},
+	})
+	require.Nil(t, err, "Should execute request")
+	assert.Equal(t, "docker /v1.43/containers/json?all=1", string(executedRequestResponses[0].Response.Body), "Should keep host and path, without proxy")
+}
+
+func testUsesUnixSocketFromProfile(t *testing.T) {
+	profilesDir := profile.SetupTestProfilesDir()
+	profile.CreateTestProfile("docker", "baseURL: http://localhost\nunixSocket: docker.sock\n", profilesDir)
+
+	server := createUnixSocketServer(t, filepath.Join(profilesDir, "docker.sock"))
+	defer server.Close()
+
+	executedRequestResponses, err := executeWithProfile(t, "docker", "", "/_ping")
+	require.Nil(t, err, "Should execute request")
+	assert.Equal(t, "localhost /_ping", string(executedRequestResponses[0].Response.Body), "Should send request to socket from profile")
+}
+
+func testRecordsTimeToFirstByteAndTransfer(t *testing.T) {
+	server := createSlowServer(50 * time.Millisecond)
+	defer server.Close()
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{Request: Request{URL: server.URL + "/slow-headers"}})
+	require.Nil(t, err, "Should execute request")
+	timings := executedRequestResponses[0].Response.Timings
+	assert.True(t, timings.FirstByte >= 50*time.Millisecond, "Should wait for first byte, was %s", timings.FirstByte)
+	assert.True(t, timings.Total >= timings.FirstByte+timings.Transfer, "Should include all phases in total")
+
+	executedRequestResponses, err = ExecuteRequestLoop(ExecutionContext{Request: Request{URL: server.URL + "/slow-body"}})
+	require.Nil(t, err, "Should execute request")
+	timings = executedRequestResponses[0].Response.Timings
+	assert.True(t, timings.Transfer >= 50*time.Millisecond, "Should wait for body, was %s", timings.Transfer)
+	assert.True(t, timings.FirstByte < 50*time.Millisecond, "Should get first byte before body, was %s", timings.FirstByte)
+}
+
+func testRecordsConnectionAndTLSHandshake(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "timed")
+	}))
+	defer server.Close()
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		AllowInsecure: true,
+		Request:       Request{URL: strings.Replace(server.URL, "127.0.0.1", "localhost", 1)},
+	})
+	require.Nil(t, err, "Should execute request")
+
+	timings := executedRequestResponses[0].Response.Timings
+	assert.True(t, timings.DNSLookup > 0, "Should look up host name")
+	assert.True(t, timings.Connect > 0, "Should connect")
+	assert.True(t, timings.TLSHandshake > 0, "Should do TLS handshake")
+	assert.False(t, timings.ReusedConnection, "Should not reuse connection")
+}
+
+func testStreamsBodyToOutputFile(t *testing.T) {
+	content := bytes.Repeat([]byte{0, 0xff, 0xfe, '\r', '\n'}, 100000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(content)
+	}))
+	defer server.Close()
+
+	dir, dirErr := ioutil.TempDir("", "download")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	var lastProgress progress.Progress
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		Download:   download.Options{Directory: dir, RemoteName: true},
+		OnProgress: func(transferred progress.Progress) { lastProgress = transferred },
+		Request:    Request{URL: server.URL + "/artifact.bin"},
+	})
+	require.Nil(t, err, "Should execute request")
+
+	response := executedRequestResponses[0].Response
+	assert.Empty(t, response.Body, "Should not keep the body in memory")
+	require.NotNil(t, response.Download, "Should save the body")
+
+	saved, _ := ioutil.ReadFile(filepath.Join(dir, "artifact.bin"))
+	assert.Equal(t, content, saved, "Should save binary body as it is")
+
+	hash := sha256.Sum256(content)
+	assert.Equal(t, hex.EncodeToString(hash[:]), response.Download.SHA256, "Should calculate checksum")
+	assert.Equal(t, int64(len(content)), response.Download.Size, "Should return size")
+	assert.True(t, lastProgress.Done, "Should report progress until done")
+	assert.Equal(t, int64(len(content)), lastProgress.Total, "Should report total from Content-Length")
+}
+
+func testSavesOnlyBodyAfterRedirects(t *testing.T) {
+	server := createRedirectServer()
+	defer server.Close()
+
+	dir, dirErr := ioutil.TempDir("", "download")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	outputFile := filepath.Join(dir, "output.txt")
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		Download:       download.Options{File: outputFile},
+		FollowLocation: true,
+		MaxRedirect:    10,
+		Request: Request{
+			Headers: map[string][]string{"X-Custom": {"saved"}},
+			URL:     server.URL + "/redirect?status=302&to=/echo",
+		},
+	})
+	require.Nil(t, err, "Should execute request")
+	require.Equal(t, 2, len(executedRequestResponses), "Should follow redirect")
+	assert.Nil(t, executedRequestResponses[0].Response.Download, "Should not save redirect")
+
+	saved, _ := ioutil.ReadFile(outputFile)
+	assert.Equal(t, "GET  saved ", string(saved), "Should save body from the last response")
+}
+
+// createFlakyServer creates a server that responds with 503 and Retry-After for the number of
+// requests specified and then 200 for all requests after that
+func testStreamsFileWithContentLength(t *testing.T) {
+	content := bytes.Repeat([]byte{0, 0xff, 0xfe, '\r', '\n'}, 100000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hash := sha256.New()
+		io.Copy(hash, r.Body)
+		fmt.Fprintf(w, "%d %v %x", r.ContentLength, r.TransferEncoding, hash.Sum(nil))
+	}))
+	defer server.Close()
+
+	dir, dirErr := ioutil.TempDir("", "upload")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "dump.bin")
+	require.Nil(t, ioutil.WriteFile(file, content, 0600), "Should write file to upload")
+
+	var lastProgress progress.Progress
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		OnProgress: func(transferred progress.Progress) { lastProgress = transferred },
+		Request:    Request{BodyFile: file, Method: http.MethodPut, URL: server.URL},
+	})
+	require.Nil(t, err, "Should execute request")
+
+	hash := sha256.Sum256(content)
+	assert.Equal(t, fmt.Sprintf("%d [] %x", len(content), hash), string(executedRequestResponses[0].Response.Body), "Should send the file with its size")
+	assert.True(t, lastProgress.Upload, "Should report upload progress")
+	assert.True(t, lastProgress.Done, "Should report progress until done")
+	assert.Equal(t, int64(len(content)), lastProgress.Transferred, "Should report what was sent")
+}
+
+func testSendsFileAgainWhenFollowingRedirects(t *testing.T) {
+	server := createRedirectServer()
+	defer server.Close()
+
+	dir, dirErr := ioutil.TempDir("", "upload")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "body.json")
+	require.Nil(t, ioutil.WriteFile(file, []byte(`{"id":1}`), 0600), "Should write file to upload")
+
+	executedRequestResponses, err := ExecuteRequestLoop(ExecutionContext{
+		FollowLocation: true,
+		MaxRedirect:    10,
+		Request: Request{
+			BodyFile: file,
+			Headers:  map[string][]string{"Content-Type": {"application/json"}},
+			Method:   http.MethodPut,
+			URL:      fmt.Sprintf("%s/redirect?status=%d&to=/echo", server.URL, http.StatusTemporaryRedirect),
+		},
+	})
+
+	require.Nil(t, err, "Should execute request")
+	require.Equal(t, 2, len(executedRequestResponses), "Should record each hop")
+	assert.Equal(t, `PUT application/json  {"id":1}`, string(executedRequestResponses[1].Response.Body), "Should stream the file again")
+}
+
+func testDoesNotSendSingleUseBodyAgain(t *testing.T) {
+	redirectServer := createRedirectServer()
+	defer redirectServer.Close()
+
+	flakyServer := createFlakyServer(1)
+	defer flakyServer.Close()
+
+	dir, dirErr := ioutil.TempDir("", "upload")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "stdin")
+	require.Nil(t, ioutil.WriteFile(file, []byte(`{"id":1}`), 0600), "Should write file to upload")
+
+	execute := func(url string) ([]ExecutedRequestResponse, error) {
+		return ExecuteRequestLoop(ExecutionContext{
+			FollowLocation: true,
+			MaxRedirect:    10,
+			Request: Request{
+				BodyFile:      file,
+				Method:        http.MethodPut,
+				Retry:         retry.Options{Attempts: 3, Delay: time.Millisecond},
+				SingleUseBody: true,
+				URL:           url,
+			},
+		})
+	}
+
+	redirected, redirectErr := execute(fmt.Sprintf("%s/redirect?status=%d&to=/echo", redirectServer.URL, http.StatusTemporaryRedirect))
+	require.NotNil(t, redirectErr, "Should fail to follow redirect that keeps the body")
+	assert.Contains(t, redirectErr.Error(), "follow the redirect", "Should say what it couldn't do")
+	assert.Equal(t, 1, len(redirected), "Should return the redirect response")
+
+	changedToGet, changedToGetErr := execute(fmt.Sprintf("%s/redirect?status=%d&to=/echo", redirectServer.URL, http.StatusSeeOther))
+	require.Nil(t, changedToGetErr, "Should follow redirect without the body")
+	assert.Equal(t, 2, len(changedToGet), "Should record each hop")
+
+	retried, retryErr := execute(flakyServer.URL)
+	require.NotNil(t, retryErr, "Should fail to retry")
+	assert.Contains(t, retryErr.Error(), "retry the request", "Should say what it couldn't do")
+	require.Equal(t, 1, len(retried), "Should return the failed attempt")
+	assert.Equal(t, http.StatusServiceUnavailable, retried[0].Response.StatusCode, "Should return the failed response")
+}
+
+func testStreamsMultipartFormFromNamedRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if parseErr := r.ParseMultipartForm(1024 * 1024); parseErr != nil {
+			http.Error(w, parseErr.Error(), http.StatusBadRequest)
+			return
+		}
+
+		photo, header, _ := r.FormFile("photo")
+		content, _ := ioutil.ReadAll(photo)
+		fmt.Fprintf(w, "%d %s %s %s %s", r.ContentLength, r.FormValue("description"), header.Filename, header.Header.Get("Content-Type"), content)
+	}))
+	defer server.Close()
+
+	profilesDir := profile.SetupTestProfilesDir()
+	require.Nil(t, ioutil.WriteFile(filepath.Join(profilesDir, "photo.png"), []byte("not really a PNG"), 0600), "Should write file to upload")
+	profile.CreateTestProfile("form", fmt.Sprintf(`headers:
+  Content-Type: application/json
+requests:
+  upload:
+    url: %s
+    multipart:
+      - description=Photo of {name}
+      - name: photo
+        file: photo.png
+        fileName: me.png
+variables:
+  name: John
+`, server.URL), profilesDir)
+
+	executedRequestResponses, err := executeWithProfile(t, "form", "upload", "")
+	require.Nil(t, err, "Should execute request")
+
+	response := executedRequestResponses[0].Response
+	require.Equal(t, http.StatusOK, response.StatusCode, string(response.Body))
+	assert.NotEqual(t, "-1", strings.Split(string(response.Body), " ")[0], "Should send the size of the form")
+	assert.Equal(t, "Photo of John me.png image/png not really a PNG", strings.SplitN(string(response.Body), " ", 2)[1], "Should send text and file parts")
+}
+
+func createFlakyServer(failures int) *httptest.Server {
+	count := 0
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if count <= failures {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "done")
+	}))
+}
+
+// createRedirectServer creates a server that redirects from /redirect using the status and location
+// from the query, echoes the request in /echo and the authorization header in /auth
+func createRedirectServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			statusCode, _ := strconv.Atoi(r.URL.Query().Get("status"))
+			w.Header().Set("Location", r.URL.Query().Get("to"))
+			w.WriteHeader(statusCode)
+		case "/echo":
+			body, _ := ioutil.ReadAll(r.Body)
+			fmt.Fprintf(w, "%s %s %s %s", r.Method, r.Header.Get("Content-Type"), r.Header.Get("X-Custom"), body)
+		case "/auth":
+			fmt.Fprint(w, r.Header.Get("Authorization"))
+		}
+	}))
+}
+
+// createSlowServer creates a server that waits before sending the headers in /slow-headers and
+// before sending the body in /slow-body, and redirects to itself after waiting in /slow-redirect
+func createSlowServer(delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow-headers":
+			time.Sleep(delay)
+		case "/slow-body":
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			time.Sleep(delay)
+		case "/slow-redirect":
+			time.Sleep(delay)
+			w.Header().Set("Location", "/slow-redirect")
+			w.WriteHeader(http.StatusFound)
+			return
+		}
+		fmt.Fprint(w, "done")
+	}))
+}
+
+// createH2CServer creates a server that accepts HTTP/2 without TLS and responds with the protocol used
+func createH2CServer() *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Proto)
+	}))
+	server.Config.Protocols = new(http.Protocols)
+	server.Config.Protocols.SetHTTP1(true)
+	server.Config.Protocols.SetUnencryptedHTTP2(true)
+	server.Start()
+	return server
+}
+
+// createUnixSocketServer creates a server listening on the Unix socket that responds with the host
+// and the path requested
+func createUnixSocketServer(t *testing.T, socket string) *httptest.Server {
+	listener, listenErr := net.Listen("unix", socket)
+	require.Nil(t, listenErr, "Should listen on Unix socket")
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s", r.Host, r.URL.RequestURI())
+	}))
+	server.Listener = listener
+	server.Start()
+	return server
+}
+
+// createMutualTLSServer creates a server that requires a client certificate and responds with its common name
+func createMutualTLSServer() *httptest.Server {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.TLS.PeerCertificates[0].Subject.CommonName)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	return server
+}
+
+func executeWithProfile(t *testing.T, profileName string, requestName string, url string) ([]ExecutedRequestResponse, error) {
+	mergedProfile, profileErr := profile.LoadAndMergeProfiles([]string{profileName})
+	require.Nil(t, profileErr, "Should load profile")
+
+	configuredRequest, configureErr := ConfigureRequest(
+		Request{URL: url},
+		&mergedProfile,
+		CreateConfigureRequestOptions(AddProfiles(profileName), SetRequestName(requestName)),
+	)
+	require.Nil(t, configureErr, "Should configure request")
+
+	return ExecuteRequestLoop(ExecutionContext{
+		AllowInsecure: true,
+		ProfileNames:  []string{profileName},
+		Request:       *configuredRequest,
+	})
+}
@@ -1,5 +1,7 @@
 package request
 
+import "github.com/visola/go-http-cli/pkg/profile"
+
 func mergeVariables(allVariables ...map[string]string) map[string]string {
 	result := make(map[string]string)
 	for i := len(allVariables) - 1; i >= 0; i-- {
@@ -10,3 +12,22 @@ func mergeVariables(allVariables ...map[string]string) map[string]string {
 	}
 	return result
 }
+
+// resolveVariableCommands runs the commands for variables that come from commands and returns all
+// variables from the profiles with their values
+func resolveVariableCommands(mergedProfiles profile.Options) (map[string]string, error) {
+	result := make(map[string]string)
+	for name, value := range mergedProfiles.Variables {
+		result[name] = value
+	}
+
+	for name, command := range mergedProfiles.VariableCommands {
+		value, runErr := command.Run()
+		if runErr != nil {
+			return nil, runErr
+		}
+		result[name] = value
+	}
+
+	return result, nil
+}
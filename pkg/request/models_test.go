@@ -10,7 +10,7 @@ import (
 
 func TestMashalUnmarshalExecutedRequestResponse(t *testing.T) {
 	req := Request{
-		Body: "Hello world!",
+		Body: []byte("Hello world!"),
 		Headers: map[string][]string{
 			"Content-Type": {"application/json"},
 		},
@@ -19,7 +19,7 @@ func TestMashalUnmarshalExecutedRequestResponse(t *testing.T) {
 	}
 
 	resp := Response{
-		Body: "Good Bye World!",
+		Body: []byte("Good Bye World!"),
 		Headers: map[string][]string{
 			"Content-Type": {"application/json"},
 		},
@@ -42,3 +42,21 @@ func TestMashalUnmarshalExecutedRequestResponse(t *testing.T) {
 	assert.Nil(t, err, "Should unmarshal correctly")
 	assert.Equal(t, pair, newPair)
 }
+
+func TestMarshalUnmarshalBinaryBodies(t *testing.T) {
+	gzipped := []byte{0x1f, 0x8b, 0x08, 0x00, 0x00, 0xff, 0xfe, 0x00}
+	pair := ExecutedRequestResponse{
+		Request:  Request{Body: gzipped, URL: "http://www.google.com"},
+		Response: Response{Body: []byte("\xff\xd8\xff\xe0 not UTF-8"), StatusCode: 200},
+	}
+
+	b, err := json.Marshal(pair)
+	assert.Nil(t, err, "Should marshal correctly")
+	assert.Contains(t, string(b), `"Body":"H4sIAAD//gA="`, "Should send body in base64")
+
+	var newPair ExecutedRequestResponse
+	err = json.Unmarshal(b, &newPair)
+
+	assert.Nil(t, err, "Should unmarshal correctly")
+	assert.Equal(t, pair, newPair, "Should keep binary bodies as they are")
+}
@@ -2,11 +2,13 @@ package request
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/mitchellh/mapstructure"
 	"github.com/op/go-logging"
 	"github.com/robertkrimen/otto"
+	"github.com/visola/go-http-cli/pkg/credential"
 	"github.com/visola/go-http-cli/pkg/profile"
 	"github.com/visola/go-http-cli/pkg/session"
 )
@@ -80,12 +82,15 @@ func createAddRequestFunction(vm *otto.Otto, context *PostProcessContext, execut
 			}
 
 			var toAdd Request
-			if err := mapstructure.Decode(toAddAsMap, &toAdd); err != nil {
+			if err := decodeRequest(toAddAsMap, &toAdd); err != nil {
 				log.Error("Error while converting map to request object.", err)
 				message := fmt.Sprintf("Error while converting map to request object.\n%s\n", err.Error())
 				panic(vm.MakeCustomError("ConversionError", message))
 			}
 
+			// Commands only run from the profiles, not from what scripts add
+			toAdd.Auth.PasswordCommand = credential.Command{}
+			toAdd.Auth.TokenCommand = credential.Command{}
 			unconfiguredRequest = toAdd
 		}
 
@@ -126,11 +131,45 @@ func preparePostProcessContext(vm *otto.Otto, executionContext *ExecutionContext
 	vm.Set("print", createPrintFunction(context))
 	vm.Set("println", createPrintlnFunction(context))
 
-	vm.Set("executed", executedRequests)
-	if len(executedRequests) > 0 {
-		vm.Set("request", executedRequests[0].Request)
-		vm.Set("response", executedRequests[0].Response)
+	executed := make([]map[string]interface{}, len(executedRequests))
+	for i, executedRequest := range executedRequests {
+		executed[i] = executedRequest.toScript()
+	}
+
+	// The request and response to process are the last ones, after retrying or logging in. The
+	// attempts and responses before them are still in executed.
+	vm.Set("executed", executed)
+	if len(executed) > 0 {
+		vm.Set("request", executed[len(executed)-1]["Request"])
+		vm.Set("response", executed[len(executed)-1]["Response"])
 	}
 
 	return context
 }
+
+// decodeRequest converts an object from a script to a request, with the body as a string
+func decodeRequest(toDecode interface{}, toAdd *Request) error {
+	decoder, decoderErr := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+			if from.Kind() == reflect.String && to == reflect.TypeOf([]byte{}) {
+				return []byte(data.(string)), nil
+			}
+			return data, nil
+		},
+		Result: toAdd,
+	})
+	if decoderErr != nil {
+		return decoderErr
+	}
+	return decoder.Decode(toDecode)
+}
+
+// scriptFields returns the fields of a struct by name, like scripts see them
+func scriptFields(value interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	structValue := reflect.ValueOf(value)
+	for i := 0; i < structValue.NumField(); i++ {
+		fields[structValue.Type().Field(i).Name] = structValue.Field(i).Interface()
+	}
+	return fields
+}
@@ -4,6 +4,7 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/visola/go-http-cli/pkg/formdata"
 	"github.com/visola/go-http-cli/pkg/profile"
 	"github.com/visola/variables/variables"
 )
@@ -16,12 +17,20 @@ func replaceRequestVariables(configuredRequest Request, mergedProfiles profile.O
 	configuredRequest.Headers = replaceVariablesInMapOfArrayOfStrings(configuredRequest.Headers, finalVariableSet)
 	configuredRequest.QueryParams = replaceVariablesInMapOfArrayOfStrings(configuredRequest.QueryParams, finalVariableSet)
 
+	configuredRequest.Auth = configuredRequest.Auth.ReplaceVariables(finalVariableSet)
+
+	configuredRequest.Proxy.URL = variables.ReplaceVariables(configuredRequest.Proxy.URL, finalVariableSet)
+	configuredRequest.Proxy.Username = variables.ReplaceVariables(configuredRequest.Proxy.Username, finalVariableSet)
+	configuredRequest.Proxy.Password = variables.ReplaceVariables(configuredRequest.Proxy.Password, finalVariableSet)
+
 	newBody, err := replaceVariablesInBody(configuredRequest, finalVariableSet)
 	if err != nil {
 		return configuredRequest, err
 	}
 	configuredRequest.Body = newBody
 
+	configuredRequest.Multipart = replaceVariablesInMultipart(configuredRequest.Multipart, finalVariableSet)
+
 	for _, cookie := range context.Session.Cookies {
 		configuredRequest.Cookies = append(configuredRequest.Cookies, cookie)
 	}
@@ -29,15 +38,15 @@ func replaceRequestVariables(configuredRequest Request, mergedProfiles profile.O
 	return configuredRequest, nil
 }
 
-func replaceVariablesInBody(configuredRequest Request, finalVariableSet map[string]string) (string, error) {
+func replaceVariablesInBody(configuredRequest Request, finalVariableSet map[string]string) ([]byte, error) {
 	contentType := getContentType(configuredRequest.Headers)
 
 	// If body is form, it might have been URL encoded with variables
 	// needs to be decoded, replaced, re-encoded
 	if strings.HasSuffix(strings.TrimSpace(contentType), urlEncodedMimeType) {
-		vals, err := url.ParseQuery(configuredRequest.Body)
+		vals, err := url.ParseQuery(string(configuredRequest.Body))
 		if err != nil {
-			return "", err
+			return nil, err
 		}
 
 		newVals := url.Values{}
@@ -48,10 +57,26 @@ func replaceVariablesInBody(configuredRequest Request, finalVariableSet map[stri
 			}
 		}
 
-		return newVals.Encode(), nil
+		return []byte(newVals.Encode()), nil
 	}
 
-	return variables.ReplaceVariables(configuredRequest.Body, finalVariableSet), nil
+	return []byte(variables.ReplaceVariables(string(configuredRequest.Body), finalVariableSet)), nil
+}
+
+// replaceVariablesInMultipart replaces variables in the names and values of text parts. Files are sent
+// as they are.
+func replaceVariablesInMultipart(parts []formdata.Part, finalVariableSet map[string]string) []formdata.Part {
+	if len(parts) == 0 {
+		return parts
+	}
+
+	result := make([]formdata.Part, len(parts))
+	for index, part := range parts {
+		part.Name = variables.ReplaceVariables(part.Name, finalVariableSet)
+		part.Value = variables.ReplaceVariables(part.Value, finalVariableSet)
+		result[index] = part
+	}
+	return result
 }
 
 func replaceVariablesInMapOfArrayOfStrings(headers map[string][]string, context map[string]string) map[string][]string {
@@ -1,23 +1,37 @@
 package request
 
 import (
-	"errors"
-	"io/ioutil"
 	"net/http"
 
+	"github.com/visola/go-http-cli/pkg/authorization"
 	"github.com/visola/go-http-cli/pkg/base"
+	"github.com/visola/go-http-cli/pkg/formdata"
 	"github.com/visola/go-http-cli/pkg/profile"
+	"github.com/visola/go-http-cli/pkg/proxy"
+	"github.com/visola/go-http-cli/pkg/retry"
+	"github.com/visola/go-http-cli/pkg/timeout"
+	"github.com/visola/go-http-cli/pkg/tlsconfig"
 )
 
 // Request stores data required to configure a request to be executed
 type Request struct {
 	AllowInsecure   bool
-	Body            string
+	Auth            authorization.Authorization
+	Body            []byte
+	BodyFile        string // File streamed as the body, without loading it in memory
 	Cookies         []*http.Cookie
 	Headers         map[string][]string
+	HTTPVersion     string // Version of the protocol to use, negotiated if not set
 	Method          string
+	Multipart       []formdata.Part // Parts of a multipart form body, files are streamed
 	PostProcessCode PostProcessSourceCode
+	Proxy           proxy.Options
 	QueryParams     map[string][]string
+	Retry           retry.Options
+	SingleUseBody   bool // The body is read from a pipe, like the standard input, and can only be sent once
+	Timeouts        timeout.Options
+	TLS             tlsconfig.Options
+	UnixSocket      string // Unix socket to connect to instead of the host in the URL
 	URL             string
 }
 
@@ -26,9 +40,19 @@ func (req Request) GetAllowInsecure() bool {
 	return req.AllowInsecure
 }
 
+// GetAuth returns the authorization for this request
+func (req Request) GetAuth() (authorization.Authorization, error) {
+	return req.Auth, nil
+}
+
 // GetBody returns the body for this request
 func (req Request) GetBody() (string, error) {
-	return req.Body, nil
+	return string(req.Body), nil
+}
+
+// GetBodyFile returns the file to be streamed as the body for this request
+func (req Request) GetBodyFile() (string, error) {
+	return req.BodyFile, nil
 }
 
 // GetHeaders returns the headers for this request
@@ -36,32 +60,71 @@ func (req Request) GetHeaders() map[string][]string {
 	return req.Headers
 }
 
+// GetHTTPVersion returns the version of the protocol to use for this request
+func (req Request) GetHTTPVersion() string {
+	return req.HTTPVersion
+}
+
 // GetMethod returns the HTTP method for this request
 func (req Request) GetMethod() string {
 	return req.Method
 }
 
-// LoadBodyFromFile loads data from a file and set it to the body, if not already set
-func (req *Request) LoadBodyFromFile(fileName string) error {
-	if fileName == "" {
-		return nil
-	}
+// GetMultipart returns the parts of the multipart form body for this request
+func (req Request) GetMultipart() ([]formdata.Part, error) {
+	return req.Multipart, nil
+}
 
-	data, loadError := ioutil.ReadFile(fileName)
-	if loadError != nil {
-		return loadError
-	}
+// GetProxy returns the proxy configuration for this request
+func (req Request) GetProxy() proxy.Options {
+	return req.Proxy
+}
 
-	if req.Body != "" {
-		return errors.New("Cannot set body and try to load from file at the same time")
-	}
+// GetRetry returns the retry policy for this request
+func (req Request) GetRetry() retry.Options {
+	return req.Retry
+}
 
-	req.Body = string(data)
-	return nil
+// GetTimeouts returns the timeouts for this request
+func (req Request) GetTimeouts() timeout.Options {
+	return req.Timeouts
+}
+
+// GetTLS returns the TLS options for this request
+func (req Request) GetTLS() (tlsconfig.Options, error) {
+	return req.TLS, nil
+}
+
+// GetUnixSocket returns the Unix socket to send this request to
+func (req Request) GetUnixSocket() (string, error) {
+	return req.UnixSocket, nil
+}
+
+// toScript converts the request to what post processing scripts get, with the body as a string
+func (req Request) toScript() map[string]interface{} {
+	fields := scriptFields(req)
+	fields["Body"] = string(req.Body)
+	return fields
 }
 
 // Merge merges information from something compatible with a request into this request
 func (req *Request) Merge(toMerge interface{}) error {
+	if withMultipart, ok := toMerge.(base.WithMultipart); ok {
+		parts, err := withMultipart.GetMultipart()
+		if err != nil {
+			return err
+		}
+		req.MergeMultipart(parts)
+	}
+
+	if withBodyFile, ok := toMerge.(base.WithBodyFile); ok {
+		bodyFile, err := withBodyFile.GetBodyFile()
+		if err != nil {
+			return err
+		}
+		req.MergeBodyFile(bodyFile)
+	}
+
 	if withBody, ok := toMerge.(base.WithBody); ok {
 		body, err := withBody.GetBody()
 		if err != nil {
@@ -70,10 +133,54 @@ func (req *Request) Merge(toMerge interface{}) error {
 		req.MergeBody(body)
 	}
 
+	if withAuth, ok := toMerge.(base.WithAuth); ok {
+		auth, err := withAuth.GetAuth()
+		if err != nil {
+			return err
+		}
+		req.Auth = req.Auth.Merge(auth)
+	}
+
 	if withAllowInsecure, ok := toMerge.(base.WithAllowInsecure); ok {
 		req.AllowInsecure = req.AllowInsecure || withAllowInsecure.GetAllowInsecure()
 	}
 
+	if withHTTPVersion, ok := toMerge.(base.WithHTTPVersion); ok {
+		if withHTTPVersion.GetHTTPVersion() != "" {
+			req.HTTPVersion = withHTTPVersion.GetHTTPVersion()
+		}
+	}
+
+	if withProxy, ok := toMerge.(base.WithProxy); ok {
+		req.Proxy = req.Proxy.Merge(withProxy.GetProxy())
+	}
+
+	if withRetry, ok := toMerge.(base.WithRetry); ok {
+		req.Retry = req.Retry.Merge(withRetry.GetRetry())
+	}
+
+	if withTimeouts, ok := toMerge.(base.WithTimeouts); ok {
+		req.Timeouts = req.Timeouts.Merge(withTimeouts.GetTimeouts())
+	}
+
+	if withTLS, ok := toMerge.(base.WithTLS); ok {
+		tlsOptions, err := withTLS.GetTLS()
+		if err != nil {
+			return err
+		}
+		req.TLS = req.TLS.Merge(tlsOptions)
+	}
+
+	if withUnixSocket, ok := toMerge.(base.WithUnixSocket); ok {
+		unixSocket, err := withUnixSocket.GetUnixSocket()
+		if err != nil {
+			return err
+		}
+		if unixSocket != "" {
+			req.UnixSocket = unixSocket
+		}
+	}
+
 	if withHeader, ok := toMerge.(base.WithHeaders); ok {
 		req.MergeHeaders(withHeader.GetHeaders())
 	}
@@ -95,10 +202,32 @@ func (req *Request) Merge(toMerge interface{}) error {
 	return nil
 }
 
-// MergeBody merges a body with this request
+// MergeBody merges a body with this request, replacing the file to stream and the multipart form
 func (req *Request) MergeBody(toMerge string) {
 	if toMerge != "" {
-		req.Body = toMerge
+		req.Body = []byte(toMerge)
+		req.BodyFile = ""
+		req.Multipart = nil
+	}
+}
+
+// MergeBodyFile merges a file to stream as body with this request, replacing the body and the
+// multipart form
+func (req *Request) MergeBodyFile(toMerge string) {
+	if toMerge != "" {
+		req.Body = nil
+		req.BodyFile = toMerge
+		req.Multipart = nil
+	}
+}
+
+// MergeMultipart merges the parts of a multipart form with this request, replacing the body and
+// the file to stream
+func (req *Request) MergeMultipart(toMerge []formdata.Part) {
+	if len(toMerge) > 0 {
+		req.Body = nil
+		req.BodyFile = ""
+		req.Multipart = toMerge
 	}
 }
 
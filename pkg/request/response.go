@@ -1,10 +1,23 @@
 package request
 
+import "github.com/visola/go-http-cli/pkg/download"
+
 // Response is the response from the daemon after executing a request
 type Response struct {
-	Body       string
+	Body       []byte
+	Download   *download.Result // Set if the body was saved to a file instead of kept in Body
 	Headers    map[string][]string
 	Protocol   string
 	StatusCode int
 	Status     string
+	Timings    Timings
+}
+
+// toScript converts the response to what post processing scripts get, with the body as a string.
+// Timings are also available like in JSON, in milliseconds: response.timings.firstByte
+func (response Response) toScript() map[string]interface{} {
+	fields := scriptFields(response)
+	fields["Body"] = string(response.Body)
+	fields["timings"] = response.Timings.toScript()
+	return fields
 }
@@ -0,0 +1,147 @@
+package request
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings is how long each phase of a request took. Phases that didn't happen are zero, like the DNS
+// lookup when connecting to an IP address or the connection when one is reused.
+type Timings struct {
+	Connect          time.Duration // Establishing the connection to the server or to the proxy
+	DNSLookup        time.Duration
+	FirstByte        time.Duration // From sending the request until the first byte of the response
+	ReusedConnection bool
+	TLSHandshake     time.Duration
+	Total            time.Duration
+	Transfer         time.Duration // From the first byte of the response until the body was read
+}
+
+// toScript converts the timings to what post processing scripts get, with the times in milliseconds
+func (timings Timings) toScript() map[string]interface{} {
+	milliseconds := func(duration time.Duration) float64 {
+		return float64(duration) / float64(time.Millisecond)
+	}
+
+	return map[string]interface{}{
+		"connect":          milliseconds(timings.Connect),
+		"dnsLookup":        milliseconds(timings.DNSLookup),
+		"firstByte":        milliseconds(timings.FirstByte),
+		"reusedConnection": timings.ReusedConnection,
+		"tlsHandshake":     milliseconds(timings.TLSHandshake),
+		"total":            milliseconds(timings.Total),
+		"transfer":         milliseconds(timings.Transfer),
+	}
+}
+
+// timingsTracer records when each phase of a request started and finished. The hooks can be called
+// from the goroutines that dial the connection, so they are synchronized.
+type timingsTracer struct {
+	connectDone  time.Time
+	connectStart time.Time
+	dnsDone      time.Time
+	dnsStart     time.Time
+	firstByte    time.Time
+	mutex        sync.Mutex
+	reused       bool
+	start        time.Time
+	tlsDone      time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+}
+
+func newTimingsTracer() *timingsTracer {
+	return &timingsTracer{start: time.Now()}
+}
+
+// withContext returns a context that reports the phases of the request to this tracer
+func (tracer *timingsTracer) withContext(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		ConnectDone: func(_ string, _ string, err error) {
+			if err == nil {
+				tracer.record(&tracer.connectDone)
+			}
+		},
+		ConnectStart: func(_ string, _ string) {
+			tracer.recordFirst(&tracer.connectStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			tracer.record(&tracer.dnsDone)
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			tracer.record(&tracer.dnsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			tracer.mutex.Lock()
+			defer tracer.mutex.Unlock()
+			tracer.reused = info.Reused
+		},
+		GotFirstResponseByte: func() {
+			tracer.record(&tracer.firstByte)
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				tracer.record(&tracer.tlsDone)
+			}
+		},
+		TLSHandshakeStart: func() {
+			tracer.record(&tracer.tlsStart)
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			tracer.record(&tracer.wroteRequest)
+		},
+	})
+}
+
+// gotResponse records the first byte of the response for transports that don't report it, like the
+// one for HTTP/3
+func (tracer *timingsTracer) gotResponse() {
+	tracer.recordFirst(&tracer.firstByte)
+}
+
+// timings calculates how long each phase took, with the body read at the end
+func (tracer *timingsTracer) timings(end time.Time) Timings {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+
+	sent := tracer.wroteRequest
+	if sent.IsZero() {
+		sent = tracer.start
+	}
+
+	return Timings{
+		Connect:          between(tracer.connectStart, tracer.connectDone),
+		DNSLookup:        between(tracer.dnsStart, tracer.dnsDone),
+		FirstByte:        between(sent, tracer.firstByte),
+		ReusedConnection: tracer.reused,
+		TLSHandshake:     between(tracer.tlsStart, tracer.tlsDone),
+		Total:            between(tracer.start, end),
+		Transfer:         between(tracer.firstByte, end),
+	}
+}
+
+func (tracer *timingsTracer) record(moment *time.Time) {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	*moment = time.Now()
+}
+
+// recordFirst records the moment only if it wasn't recorded yet
+func (tracer *timingsTracer) recordFirst(moment *time.Time) {
+	tracer.mutex.Lock()
+	defer tracer.mutex.Unlock()
+	if moment.IsZero() {
+		*moment = time.Now()
+	}
+}
+
+// between returns how long it took from start to end, or zero if any of them didn't happen
+func between(start time.Time, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+	return end.Sub(start)
+}
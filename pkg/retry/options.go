@@ -0,0 +1,224 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/visola/go-http-cli/pkg/timeout"
+)
+
+const (
+	// ConnectErrorCondition retries when the connection to the server can't be established
+	ConnectErrorCondition = "connect-error"
+
+	// TimeoutCondition retries when the request times out
+	TimeoutCondition = "timeout"
+
+	// DefaultDelay is how long to wait before the first retry if no delay is configured
+	DefaultDelay = time.Second
+
+	// DefaultMaxDelay is the longest time to wait between two retries if no maximum is configured
+	DefaultMaxDelay = 30 * time.Second
+)
+
+// DefaultConditions is what triggers a retry if no conditions are configured
+var DefaultConditions = []string{"408", "429", "500", "502", "503", "504", ConnectErrorCondition, TimeoutCondition}
+
+// Methods that can be sent more than once without changing the result
+var idempotentMethods = map[string]bool{
+	http.MethodDelete:  true,
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodTrace:   true,
+}
+
+// Options configures how failed requests are retried
+type Options struct {
+	Attempts      int           // How many times to retry, zero means no retries
+	Delay         time.Duration // Time to wait before the first retry, doubles for each retry after that
+	MaxDelay      time.Duration // Maximum time to wait between two retries, even if the server asks for more
+	NonIdempotent bool          // Retry methods that are not idempotent, like POST and PATCH
+	On            []string      // Status codes and conditions that trigger a retry
+}
+
+// Merge returns new options with the values set in toMerge overriding the ones in these options
+func (options Options) Merge(toMerge Options) Options {
+	result := options
+
+	if toMerge.Attempts != 0 {
+		result.Attempts = toMerge.Attempts
+	}
+
+	if toMerge.Delay != 0 {
+		result.Delay = toMerge.Delay
+	}
+
+	if toMerge.MaxDelay != 0 {
+		result.MaxDelay = toMerge.MaxDelay
+	}
+
+	if toMerge.NonIdempotent {
+		result.NonIdempotent = true
+	}
+
+	if len(toMerge.On) > 0 {
+		result.On = toMerge.On
+	}
+
+	return result
+}
+
+// Backoff calculates how long to wait before the retry with the specified number, starting at 1.
+// A Retry-After value sent by the server takes precedence over the exponential backoff. The delay
+// is never longer than the maximum delay or, if the deadline is set, than the time left until it.
+func (options Options) Backoff(retryNumber int, retryAfter string, now time.Time, deadline time.Time) time.Duration {
+	maxDelay := options.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = DefaultMaxDelay
+	}
+
+	if !deadline.IsZero() && deadline.Sub(now) < maxDelay {
+		maxDelay = deadline.Sub(now)
+		if maxDelay < 0 {
+			maxDelay = 0
+		}
+	}
+
+	delay, hasRetryAfter := parseRetryAfter(retryAfter, now)
+	if !hasRetryAfter {
+		delay = options.Delay
+		if delay == 0 {
+			delay = DefaultDelay
+		}
+
+		for i := 1; i < retryNumber && delay < maxDelay; i++ {
+			delay *= 2
+		}
+	}
+
+	if delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
+
+// ShouldRetry checks if a request with the specified method that failed with the status code or
+// error needs to be retried. Returns the condition that triggered the retry.
+func (options Options) ShouldRetry(method string, statusCode int, err error) (string, bool) {
+	if options.Attempts <= 0 {
+		return "", false
+	}
+
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	if !idempotentMethods[strings.ToUpper(method)] && !options.NonIdempotent {
+		return "", false
+	}
+
+	conditions := options.On
+	if len(conditions) == 0 {
+		conditions = DefaultConditions
+	}
+
+	condition := conditionFor(statusCode, err)
+	if condition == "" {
+		return "", false
+	}
+
+	for _, toRetry := range conditions {
+		if toRetry == condition {
+			return condition, true
+		}
+	}
+
+	return "", false
+}
+
+// ParseConditions parses a comma separated list of status codes and conditions
+func ParseConditions(toParse string) ([]string, error) {
+	if strings.TrimSpace(toParse) == "" {
+		return nil, nil
+	}
+
+	conditions := make([]string, 0)
+	for _, condition := range strings.Split(toParse, ",") {
+		condition = strings.ToLower(strings.TrimSpace(condition))
+		if validateErr := validateCondition(condition); validateErr != nil {
+			return nil, validateErr
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+// conditionFor returns the condition that represents the status code or error
+func conditionFor(statusCode int, err error) string {
+	if err == nil {
+		return strconv.Itoa(statusCode)
+	}
+
+	var timeoutErr timeout.Error
+	if errors.As(err, &timeoutErr) {
+		switch timeoutErr.Kind {
+		case timeout.ConnectKind:
+			return ConnectErrorCondition
+		case timeout.MaxTimeKind:
+			// Retrying won't help if there's no time left
+			return ""
+		}
+		return TimeoutCondition
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return ConnectErrorCondition
+	}
+
+	return ""
+}
+
+// parseRetryAfter parses the value of the Retry-After header, which can be in seconds or a date
+func parseRetryAfter(retryAfter string, now time.Time) (time.Duration, bool) {
+	retryAfter = strings.TrimSpace(retryAfter)
+	if retryAfter == "" {
+		return 0, false
+	}
+
+	if seconds, parseErr := strconv.Atoi(retryAfter); parseErr == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	retryAt, parseErr := http.ParseTime(retryAfter)
+	if parseErr != nil {
+		return 0, false
+	}
+
+	if delay := retryAt.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
+func validateCondition(condition string) error {
+	if condition == ConnectErrorCondition || condition == TimeoutCondition {
+		return nil
+	}
+
+	if statusCode, parseErr := strconv.Atoi(condition); parseErr == nil && statusCode >= 100 && statusCode <= 599 {
+		return nil
+	}
+
+	return fmt.Errorf("Invalid retry condition '%s', must be a status code, %s or %s", condition, ConnectErrorCondition, TimeoutCondition)
+}
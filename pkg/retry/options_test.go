@@ -0,0 +1,135 @@
+package retry
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/visola/go-http-cli/pkg/timeout"
+)
+
+func TestShouldRetry(t *testing.T) {
+	t.Run("Retries on default conditions", testRetriesOnDefaultConditions)
+	t.Run("Retries only on configured conditions", testRetriesOnConfiguredConditions)
+	t.Run("Retries non idempotent methods only if allowed", testRetriesNonIdempotentOnlyIfAllowed)
+	t.Run("Doesn't retry without attempts", testDoesNotRetryWithoutAttempts)
+}
+
+func TestBackoff(t *testing.T) {
+	t.Run("Doubles delay up to the maximum", testDoublesDelayUpToMaximum)
+	t.Run("Honors Retry-After", testHonorsRetryAfter)
+	t.Run("Limits Retry-After to the maximum and the deadline", testLimitsRetryAfter)
+}
+
+func TestParseConditions(t *testing.T) {
+	conditions, err := ParseConditions(" 503,Connect-Error , timeout")
+	assert.Nil(t, err, "Should parse conditions")
+	assert.Equal(t, []string{"503", ConnectErrorCondition, TimeoutCondition}, conditions, "Should parse and normalize conditions")
+
+	conditions, err = ParseConditions("")
+	assert.Nil(t, err, "Should parse empty conditions")
+	assert.Nil(t, conditions, "Should return no conditions")
+
+	for _, invalid := range []string{"600", "sometimes", "503,"} {
+		_, err = ParseConditions(invalid)
+		assert.NotNil(t, err, "Should fail to parse '%s'", invalid)
+	}
+}
+
+func testRetriesOnDefaultConditions(t *testing.T) {
+	options := Options{Attempts: 3}
+
+	reason, shouldRetry := options.ShouldRetry(http.MethodGet, http.StatusServiceUnavailable, nil)
+	assert.True(t, shouldRetry, "Should retry 503")
+	assert.Equal(t, "503", reason, "Should return the status code as reason")
+
+	_, shouldRetry = options.ShouldRetry("", http.StatusTooManyRequests, nil)
+	assert.True(t, shouldRetry, "Should retry 429 with default method")
+
+	_, shouldRetry = options.ShouldRetry(http.MethodGet, http.StatusNotFound, nil)
+	assert.False(t, shouldRetry, "Should not retry 404")
+
+	dialErr := &url.Error{Op: "Get", URL: "http://localhost", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+	reason, shouldRetry = options.ShouldRetry(http.MethodGet, 0, dialErr)
+	assert.True(t, shouldRetry, "Should retry connection errors")
+	assert.Equal(t, ConnectErrorCondition, reason, "Should return connect error as reason")
+
+	reason, shouldRetry = options.ShouldRetry(http.MethodGet, 0, timeout.Error{Kind: timeout.ConnectKind})
+	assert.True(t, shouldRetry, "Should retry connect timeouts")
+	assert.Equal(t, ConnectErrorCondition, reason, "Should treat connect timeout as connect error")
+
+	reason, shouldRetry = options.ShouldRetry(http.MethodGet, 0, timeout.Error{Kind: timeout.ResponseHeaderKind})
+	assert.True(t, shouldRetry, "Should retry timeouts")
+	assert.Equal(t, TimeoutCondition, reason, "Should return timeout as reason")
+
+	_, shouldRetry = options.ShouldRetry(http.MethodGet, 0, timeout.Error{Kind: timeout.MaxTimeKind})
+	assert.False(t, shouldRetry, "Should not retry when out of time")
+
+	_, shouldRetry = options.ShouldRetry(http.MethodGet, 0, errors.New("some other error"))
+	assert.False(t, shouldRetry, "Should not retry other errors")
+}
+
+func testRetriesOnConfiguredConditions(t *testing.T) {
+	options := Options{Attempts: 1, On: []string{"502"}}
+
+	_, shouldRetry := options.ShouldRetry(http.MethodGet, http.StatusBadGateway, nil)
+	assert.True(t, shouldRetry, "Should retry configured status code")
+
+	_, shouldRetry = options.ShouldRetry(http.MethodGet, http.StatusServiceUnavailable, nil)
+	assert.False(t, shouldRetry, "Should not retry status code that is not configured")
+}
+
+func testRetriesNonIdempotentOnlyIfAllowed(t *testing.T) {
+	options := Options{Attempts: 1}
+
+	_, shouldRetry := options.ShouldRetry(http.MethodPost, http.StatusServiceUnavailable, nil)
+	assert.False(t, shouldRetry, "Should not retry POST by default")
+
+	_, shouldRetry = options.ShouldRetry(http.MethodPut, http.StatusServiceUnavailable, nil)
+	assert.True(t, shouldRetry, "Should retry PUT by default")
+
+	options.NonIdempotent = true
+	_, shouldRetry = options.ShouldRetry(http.MethodPost, http.StatusServiceUnavailable, nil)
+	assert.True(t, shouldRetry, "Should retry POST when allowed")
+}
+
+func testDoesNotRetryWithoutAttempts(t *testing.T) {
+	_, shouldRetry := Options{}.ShouldRetry(http.MethodGet, http.StatusServiceUnavailable, nil)
+	assert.False(t, shouldRetry, "Should not retry without attempts")
+}
+
+func testDoublesDelayUpToMaximum(t *testing.T) {
+	options := Options{Delay: time.Second, MaxDelay: 5 * time.Second}
+	now := time.Now()
+
+	assert.Equal(t, time.Second, options.Backoff(1, "", now, time.Time{}), "Should wait the delay before first retry")
+	assert.Equal(t, 2*time.Second, options.Backoff(2, "", now, time.Time{}), "Should double the delay")
+	assert.Equal(t, 4*time.Second, options.Backoff(3, "", now, time.Time{}), "Should double the delay again")
+	assert.Equal(t, 5*time.Second, options.Backoff(4, "", now, time.Time{}), "Should not wait longer than the maximum")
+	assert.Equal(t, DefaultDelay, Options{}.Backoff(1, "", now, time.Time{}), "Should use default delay")
+}
+
+func testHonorsRetryAfter(t *testing.T) {
+	options := Options{Delay: time.Second, MaxDelay: 5 * time.Minute}
+	now := time.Date(2020, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, 60*time.Second, options.Backoff(1, "60", now, time.Time{}), "Should wait the seconds from Retry-After")
+	assert.Equal(t, 90*time.Second, options.Backoff(1, "Wed, 01 Jan 2020 10:01:30 GMT", now, time.Time{}), "Should wait until the date from Retry-After")
+	assert.Equal(t, time.Duration(0), options.Backoff(1, "Wed, 01 Jan 2020 09:00:00 GMT", now, time.Time{}), "Should not wait for dates in the past")
+	assert.Equal(t, time.Second, options.Backoff(1, "soon", now, time.Time{}), "Should ignore invalid Retry-After")
+}
+
+func testLimitsRetryAfter(t *testing.T) {
+	options := Options{Delay: time.Second, MaxDelay: 5 * time.Second}
+	now := time.Now()
+
+	assert.Equal(t, 5*time.Second, options.Backoff(1, "3600", now, time.Time{}), "Should not wait longer than the maximum")
+	assert.Equal(t, DefaultMaxDelay, Options{}.Backoff(1, "3600", now, time.Time{}), "Should not wait longer than the default maximum")
+	assert.Equal(t, 2*time.Second, options.Backoff(1, "3600", now, now.Add(2*time.Second)), "Should not wait past the deadline")
+	assert.Equal(t, 2*time.Second, options.Backoff(3, "", now, now.Add(2*time.Second)), "Should not back off past the deadline")
+	assert.Equal(t, time.Duration(0), options.Backoff(1, "", now, now.Add(-time.Second)), "Should not wait after the deadline")
+}
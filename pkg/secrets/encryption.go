@@ -0,0 +1,121 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keySize   = 32
+	nonceSize = 12
+	saltSize  = 16
+
+	// Parameters for scrypt, as recommended for interactive logins
+	scryptN = 32768
+	scryptP = 1
+	scryptR = 8
+)
+
+// Identifies files encrypted by this package and the version of the format
+var magicHeader = []byte("GOHTTPSECRETS1")
+
+var (
+	// ErrInvalidFormat happens when trying to decrypt data that was not encrypted by this package
+	ErrInvalidFormat = errors.New("Secrets file is not in the expected format")
+
+	// ErrWrongPassphrase happens when the data can't be decrypted with the passphrase
+	ErrWrongPassphrase = errors.New("Wrong passphrase for secrets file")
+)
+
+var (
+	derivedKeys = make(map[[sha256.Size]byte][]byte)
+	keysMutex   = &sync.Mutex{}
+)
+
+// Encrypt encrypts the data using AES-GCM with a key derived from the passphrase
+func Encrypt(plainData []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	gcm, gcmErr := createGCM(passphrase, salt)
+	if gcmErr != nil {
+		return nil, gcmErr
+	}
+
+	result := append([]byte{}, magicHeader...)
+	result = append(result, salt...)
+	result = append(result, nonce...)
+	return gcm.Seal(result, nonce, plainData, magicHeader), nil
+}
+
+// Decrypt decrypts data encrypted with Encrypt
+func Decrypt(encryptedData []byte, passphrase string) ([]byte, error) {
+	headerSize := len(magicHeader) + saltSize + nonceSize
+	if len(encryptedData) < headerSize || !bytes.HasPrefix(encryptedData, magicHeader) {
+		return nil, ErrInvalidFormat
+	}
+
+	salt := encryptedData[len(magicHeader) : len(magicHeader)+saltSize]
+	nonce := encryptedData[len(magicHeader)+saltSize : headerSize]
+
+	gcm, gcmErr := createGCM(passphrase, salt)
+	if gcmErr != nil {
+		return nil, gcmErr
+	}
+
+	plainData, openErr := gcm.Open(nil, nonce, encryptedData[headerSize:], magicHeader)
+	if openErr != nil {
+		return nil, ErrWrongPassphrase
+	}
+
+	return plainData, nil
+}
+
+func createGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, keyErr := deriveKey(passphrase, salt)
+	if keyErr != nil {
+		return nil, keyErr
+	}
+
+	block, blockErr := aes.NewCipher(key)
+	if blockErr != nil {
+		return nil, blockErr
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// deriveKey derives the key from the passphrase. Keys are cached because scrypt is slow by design
+// and the daemon decrypts the secrets every time profiles are loaded.
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	cacheKey := sha256.Sum256(append([]byte(passphrase), salt...))
+
+	keysMutex.Lock()
+	defer keysMutex.Unlock()
+
+	if key, exists := derivedKeys[cacheKey]; exists {
+		return key, nil
+	}
+
+	key, keyErr := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keySize)
+	if keyErr != nil {
+		return nil, keyErr
+	}
+
+	derivedKeys[cacheKey] = key
+	return key, nil
+}
@@ -0,0 +1,44 @@
+package secrets
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryption(t *testing.T) {
+	t.Run("Decrypts encrypted data", testDecryptsEncryptedData)
+	t.Run("Fails with wrong passphrase", testFailsWithWrongPassphrase)
+	t.Run("Fails with tampered data", testFailsWithTamperedData)
+	t.Run("Fails with unknown format", testFailsWithUnknownFormat)
+}
+
+func testDecryptsEncryptedData(t *testing.T) {
+	encrypted, err := Encrypt([]byte("password: secret"), "my passphrase")
+	assert.Nil(t, err, "Should encrypt")
+	assert.NotContains(t, string(encrypted), "secret", "Should not contain plain data")
+
+	decrypted, err := Decrypt(encrypted, "my passphrase")
+	assert.Nil(t, err, "Should decrypt")
+	assert.Equal(t, "password: secret", string(decrypted), "Should return plain data")
+}
+
+func testFailsWithWrongPassphrase(t *testing.T) {
+	encrypted, _ := Encrypt([]byte("password: secret"), "my passphrase")
+
+	_, err := Decrypt(encrypted, "wrong passphrase")
+	assert.Equal(t, ErrWrongPassphrase, err, "Should fail with wrong passphrase")
+}
+
+func testFailsWithTamperedData(t *testing.T) {
+	encrypted, _ := Encrypt([]byte("password: secret"), "my passphrase")
+	encrypted[len(encrypted)-1]++
+
+	_, err := Decrypt(encrypted, "my passphrase")
+	assert.NotNil(t, err, "Should fail with tampered data")
+}
+
+func testFailsWithUnknownFormat(t *testing.T) {
+	_, err := Decrypt([]byte("password: secret"), "my passphrase")
+	assert.Equal(t, ErrInvalidFormat, err, "Should fail with plain data")
+}
@@ -0,0 +1,35 @@
+package secrets
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrLocked happens when secrets need to be decrypted but no passphrase was provided yet
+var ErrLocked = errors.New("Secrets are locked")
+
+var (
+	currentPassphrase string
+	passphraseMutex   = &sync.Mutex{}
+)
+
+// Lock forgets the passphrase
+func Lock() {
+	Unlock("")
+}
+
+// Passphrase returns the passphrase used to unlock the secrets and if it was set
+func Passphrase() (string, bool) {
+	passphraseMutex.Lock()
+	defer passphraseMutex.Unlock()
+
+	return currentPassphrase, currentPassphrase != ""
+}
+
+// Unlock stores the passphrase to decrypt secrets in memory for the lifetime of this process
+func Unlock(passphrase string) {
+	passphraseMutex.Lock()
+	defer passphraseMutex.Unlock()
+
+	currentPassphrase = passphrase
+}
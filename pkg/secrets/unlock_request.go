@@ -0,0 +1,6 @@
+package secrets
+
+// UnlockRequest represents a request to unlock the secrets in the daemon
+type UnlockRequest struct {
+	Passphrase string
+}
@@ -0,0 +1,9 @@
+package session
+
+import "github.com/visola/go-http-cli/pkg/authorization"
+
+// SetTokensRequest is used to request tokens to be stored
+type SetTokensRequest struct {
+	Key    string
+	Tokens authorization.Tokens
+}
@@ -0,0 +1,33 @@
+package session
+
+import (
+	"sync"
+
+	"github.com/visola/go-http-cli/pkg/authorization"
+)
+
+var tokens = make(map[string]authorization.Tokens)
+
+var tokensMutex = &sync.Mutex{}
+
+// GetTokens returns the tokens stored for a key, if any
+func GetTokens(key string) (authorization.Tokens, bool) {
+	tokensMutex.Lock()
+	defer tokensMutex.Unlock()
+
+	storedTokens, exists := tokens[key]
+	return storedTokens, exists
+}
+
+// SetTokens stores tokens for a key, removing it if tokens are empty
+func SetTokens(key string, toStore authorization.Tokens) {
+	tokensMutex.Lock()
+	defer tokensMutex.Unlock()
+
+	if toStore.AccessToken == "" {
+		delete(tokens, key)
+		return
+	}
+
+	tokens[key] = toStore
+}
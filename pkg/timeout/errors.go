@@ -0,0 +1,81 @@
+package timeout
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	// ConnectKind is the kind of timeout that happens while establishing the connection
+	ConnectKind = "connect"
+
+	// MaxTimeKind is the kind of timeout that happens when all requests in an execution took longer
+	// than the maximum time
+	MaxTimeKind = "max-time"
+
+	// RequestKind is the kind of timeout that happens when a single request takes too long
+	RequestKind = "request"
+
+	// ResponseHeaderKind is the kind of timeout that happens while waiting for the response headers
+	ResponseHeaderKind = "response-header"
+
+	// TLSHandshakeKind is the kind of timeout that happens during the TLS handshake
+	TLSHandshakeKind = "tls-handshake"
+)
+
+// Error is returned when a timeout is reached
+type Error struct {
+	Kind    string
+	Timeout time.Duration
+}
+
+// Error describes the timeout that expired. The duration is left out when it wasn't configured,
+// like when the operating system gives up on connecting.
+func (err Error) Error() string {
+	if err.Timeout == 0 {
+		switch err.Kind {
+		case ConnectKind:
+			return "Connection timed out"
+		case ResponseHeaderKind:
+			return "Timed out waiting for response headers"
+		case TLSHandshakeKind:
+			return "TLS handshake timed out"
+		}
+		return "Request timed out"
+	}
+
+	switch err.Kind {
+	case ConnectKind:
+		return fmt.Sprintf("Connection timed out after %s", err.Timeout)
+	case MaxTimeKind:
+		return fmt.Sprintf("Maximum time of %s for all requests reached", err.Timeout)
+	case ResponseHeaderKind:
+		return fmt.Sprintf("Timed out after %s waiting for response headers", err.Timeout)
+	case TLSHandshakeKind:
+		return fmt.Sprintf("TLS handshake timed out after %s", err.Timeout)
+	}
+	return fmt.Sprintf("Request timed out after %s", err.Timeout)
+}
+
+// DescribeError converts errors caused by the connect, TLS handshake and response header timeouts
+// into an Error. Other errors are returned as they are.
+func (options Options) DescribeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var opErr *net.OpError
+	switch {
+	case errors.As(err, &opErr) && opErr.Op == "dial" && opErr.Timeout():
+		return Error{Kind: ConnectKind, Timeout: options.Connect}
+	case strings.Contains(err.Error(), "TLS handshake timeout"):
+		return Error{Kind: TLSHandshakeKind, Timeout: options.TLSHandshake}
+	case strings.Contains(err.Error(), "timeout awaiting response headers"):
+		return Error{Kind: ResponseHeaderKind, Timeout: options.ResponseHeader}
+	}
+
+	return err
+}
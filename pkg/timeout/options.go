@@ -0,0 +1,64 @@
+package timeout
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Options configures how long each phase of a request can take. Zero means no timeout.
+type Options struct {
+	Connect        time.Duration // Time to establish the TCP connection
+	Request        time.Duration // Time for the whole request, including reading the response body
+	ResponseHeader time.Duration // Time waiting for the response headers after the request was sent
+	TLSHandshake   time.Duration // Time to complete the TLS handshake
+}
+
+// Merge returns new options with the values set in toMerge overriding the ones in these options
+func (options Options) Merge(toMerge Options) Options {
+	result := options
+
+	if toMerge.Connect != 0 {
+		result.Connect = toMerge.Connect
+	}
+
+	if toMerge.Request != 0 {
+		result.Request = toMerge.Request
+	}
+
+	if toMerge.ResponseHeader != 0 {
+		result.ResponseHeader = toMerge.ResponseHeader
+	}
+
+	if toMerge.TLSHandshake != 0 {
+		result.TLSHandshake = toMerge.TLSHandshake
+	}
+
+	return result
+}
+
+// ParseDuration parses a duration in seconds, like curl does (e.g.: 2.5), or using Go's format
+// (e.g.: 500ms). An empty string is a zero duration.
+func ParseDuration(toParse string) (time.Duration, error) {
+	if toParse == "" {
+		return 0, nil
+	}
+
+	if seconds, floatErr := strconv.ParseFloat(toParse, 64); floatErr == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("Timeout must not be negative: %s", toParse)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	duration, parseErr := time.ParseDuration(toParse)
+	if parseErr != nil {
+		return 0, fmt.Errorf("Invalid timeout '%s', use seconds (e.g.: 2.5) or a duration (e.g.: 500ms)", toParse)
+	}
+
+	if duration < 0 {
+		return 0, fmt.Errorf("Timeout must not be negative: %s", toParse)
+	}
+
+	return duration, nil
+}
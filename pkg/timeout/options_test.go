@@ -0,0 +1,71 @@
+package timeout
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	t.Run("Parses seconds", testParsesSeconds)
+	t.Run("Parses durations", testParsesDurations)
+	t.Run("Fails to parse invalid durations", testFailsToParseInvalidDurations)
+}
+
+func TestMerge(t *testing.T) {
+	options := Options{Connect: time.Second, Request: time.Minute}
+	merged := options.Merge(Options{Request: time.Second, TLSHandshake: 2 * time.Second})
+	assert.Equal(t, Options{Connect: time.Second, Request: time.Second, TLSHandshake: 2 * time.Second}, merged, "Should override values that are set")
+}
+
+func TestDescribeError(t *testing.T) {
+	options := Options{Connect: time.Second, ResponseHeader: 2 * time.Second, TLSHandshake: 3 * time.Second}
+
+	dialErr := &net.OpError{Op: "dial", Err: timeoutError{}}
+	assert.Equal(t, Error{Kind: ConnectKind, Timeout: time.Second}, options.DescribeError(dialErr), "Should describe connect timeout")
+
+	tlsErr := errors.New("net/http: TLS handshake timeout")
+	assert.Equal(t, Error{Kind: TLSHandshakeKind, Timeout: 3 * time.Second}, options.DescribeError(tlsErr), "Should describe TLS handshake timeout")
+
+	headerErr := errors.New("net/http: timeout awaiting response headers")
+	assert.Equal(t, Error{Kind: ResponseHeaderKind, Timeout: 2 * time.Second}, options.DescribeError(headerErr), "Should describe response header timeout")
+
+	assert.Equal(t, "Connection timed out after 1s", options.DescribeError(dialErr).Error(), "Should report the configured timeout")
+	assert.Equal(t, "Connection timed out", Options{}.DescribeError(dialErr).Error(), "Should leave out timeouts that aren't configured")
+
+	otherErr := errors.New("connection refused")
+	assert.Equal(t, otherErr, options.DescribeError(otherErr), "Should return other errors as they are")
+	assert.Nil(t, options.DescribeError(nil), "Should return nil without error")
+}
+
+func testParsesSeconds(t *testing.T) {
+	duration, err := ParseDuration("2.5")
+	assert.Nil(t, err, "Should parse seconds")
+	assert.Equal(t, 2500*time.Millisecond, duration, "Should parse fractions of seconds")
+
+	duration, err = ParseDuration("")
+	assert.Nil(t, err, "Should parse empty string")
+	assert.Equal(t, time.Duration(0), duration, "Should be zero for empty string")
+}
+
+func testParsesDurations(t *testing.T) {
+	duration, err := ParseDuration("1m30s")
+	assert.Nil(t, err, "Should parse duration")
+	assert.Equal(t, 90*time.Second, duration, "Should parse duration")
+}
+
+func testFailsToParseInvalidDurations(t *testing.T) {
+	for _, invalid := range []string{"soon", "-1", "-5s"} {
+		_, err := ParseDuration(invalid)
+		assert.NotNil(t, err, "Should fail to parse '%s'", invalid)
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
@@ -0,0 +1,54 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	"golang.org/x/crypto/pkcs12"
+)
+
+// loadPEM loads a certificate and private key from PEM files. If no key file is passed, the key is
+// expected to be in the same file as the certificate.
+func loadPEM(certFile string, keyFile string) (*tls.Certificate, error) {
+	if keyFile == "" {
+		keyFile = certFile
+	}
+
+	certificate, loadErr := tls.LoadX509KeyPair(certFile, keyFile)
+	if loadErr != nil {
+		return nil, fmt.Errorf("Error while loading client certificate from %s: %s", certFile, loadErr)
+	}
+
+	return &certificate, nil
+}
+
+// loadPKCS12 loads a certificate, its chain and private key from a PKCS#12 file
+func loadPKCS12(pkcs12File string, password string) (*tls.Certificate, error) {
+	data, readErr := ioutil.ReadFile(pkcs12File)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	blocks, decodeErr := pkcs12.ToPEM(data, password)
+	if decodeErr != nil {
+		return nil, fmt.Errorf("Error while decoding PKCS#12 file %s: %s", pkcs12File, decodeErr)
+	}
+
+	var certPEM, keyPEM []byte
+	for _, block := range blocks {
+		if block.Type == "CERTIFICATE" {
+			certPEM = append(certPEM, pem.EncodeToMemory(block)...)
+		} else {
+			keyPEM = append(keyPEM, pem.EncodeToMemory(block)...)
+		}
+	}
+
+	certificate, pairErr := tls.X509KeyPair(certPEM, keyPEM)
+	if pairErr != nil {
+		return nil, fmt.Errorf("Error while loading client certificate from %s: %s", pkcs12File, pairErr)
+	}
+
+	return &certificate, nil
+}
@@ -0,0 +1,56 @@
+package tlsconfig
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PinningError happens when none of the certificates sent by the server match the pinned public keys
+type PinningError struct{}
+
+func (err PinningError) Error() string {
+	return "none of the certificates presented by the server match the pinned public keys"
+}
+
+// VerificationError is returned when the TLS connection failed because one of the checks failed
+type VerificationError struct {
+	Check string
+	Err   error
+}
+
+func (err VerificationError) Error() string {
+	return fmt.Sprintf("TLS %s check failed: %s", err.Check, err.Err)
+}
+
+// DescribeError wraps errors caused by TLS verification in a VerificationError that says which
+// check failed. Other errors are returned as they are.
+func DescribeError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var invalidErr x509.CertificateInvalidError
+	var pinningErr PinningError
+
+	var check string
+	switch {
+	case errors.As(err, &unknownAuthorityErr):
+		check = "certificate authority"
+	case errors.As(err, &hostnameErr):
+		check = "hostname"
+	case errors.As(err, &invalidErr):
+		check = "certificate validity"
+	case errors.As(err, &pinningErr):
+		check = "public key pinning"
+	case strings.Contains(err.Error(), "protocol version") || strings.Contains(err.Error(), "supported versions"):
+		check = "protocol version"
+	default:
+		return err
+	}
+
+	return VerificationError{Check: check, Err: err}
+}
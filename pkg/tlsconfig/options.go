@@ -0,0 +1,171 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// Options configures how TLS connections are established
+type Options struct {
+	CAFile           string   // Path to a PEM file with the certificate authorities to trust
+	Cert             string   // Path to a PEM file with the client certificate
+	Key              string   // Path to a PEM file with the private key for the client certificate
+	MaxVersion       string   // Maximum TLS version, e.g.: 1.3
+	MinVersion       string   // Minimum TLS version, e.g.: 1.2
+	Password         string   // Password for the PKCS#12 file
+	PinnedPublicKeys []string // Base64 encoded SHA-256 hashes of the public keys to accept
+	PKCS12           string   // Path to a PKCS#12 file with the client certificate and private key
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// HasClientCertificate returns true if a client certificate is configured
+func (options Options) HasClientCertificate() bool {
+	return options.Cert != "" || options.PKCS12 != ""
+}
+
+// HasFiles returns true if any of the options point to a file
+func (options Options) HasFiles() bool {
+	return options.HasClientCertificate() || options.CAFile != ""
+}
+
+// Merge returns new options with the values from toMerge overriding the ones in these options.
+// The client certificate is replaced as a whole so that PEM and PKCS#12 files don't mix.
+func (options Options) Merge(toMerge Options) Options {
+	result := options
+
+	if toMerge.HasClientCertificate() {
+		result.Cert = toMerge.Cert
+		result.Key = toMerge.Key
+		result.Password = toMerge.Password
+		result.PKCS12 = toMerge.PKCS12
+	}
+
+	if toMerge.CAFile != "" {
+		result.CAFile = toMerge.CAFile
+	}
+
+	if toMerge.MaxVersion != "" {
+		result.MaxVersion = toMerge.MaxVersion
+	}
+
+	if toMerge.MinVersion != "" {
+		result.MinVersion = toMerge.MinVersion
+	}
+
+	if len(toMerge.PinnedPublicKeys) > 0 {
+		result.PinnedPublicKeys = toMerge.PinnedPublicKeys
+	}
+
+	return result
+}
+
+// ResolvePaths returns new options where all relative paths are resolved against the directory
+func (options Options) ResolvePaths(dir string) Options {
+	result := options
+	result.CAFile = resolvePath(dir, options.CAFile)
+	result.Cert = resolvePath(dir, options.Cert)
+	result.Key = resolvePath(dir, options.Key)
+	result.PKCS12 = resolvePath(dir, options.PKCS12)
+	return result
+}
+
+// ToConfig creates the TLS configuration to be used by the HTTP client
+func (options Options) ToConfig(allowInsecure bool) (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: allowInsecure}
+
+	var versionErr error
+	if config.MinVersion, versionErr = parseVersion(options.MinVersion); versionErr != nil {
+		return nil, versionErr
+	}
+
+	if config.MaxVersion, versionErr = parseVersion(options.MaxVersion); versionErr != nil {
+		return nil, versionErr
+	}
+
+	if config.MinVersion != 0 && config.MaxVersion != 0 && config.MinVersion > config.MaxVersion {
+		return nil, fmt.Errorf("Minimum TLS version %s is greater than maximum TLS version %s", options.MinVersion, options.MaxVersion)
+	}
+
+	if options.CAFile != "" {
+		rootCAs, caErr := loadCAFile(options.CAFile)
+		if caErr != nil {
+			return nil, caErr
+		}
+		config.RootCAs = rootCAs
+	}
+
+	if len(options.PinnedPublicKeys) > 0 {
+		verifyPins, pinsErr := createPinVerifier(options.PinnedPublicKeys)
+		if pinsErr != nil {
+			return nil, pinsErr
+		}
+		config.VerifyPeerCertificate = verifyPins
+	}
+
+	if !options.HasClientCertificate() {
+		return config, nil
+	}
+
+	if options.Cert != "" && options.PKCS12 != "" {
+		return nil, errors.New("Client certificate must be either a PEM certificate or a PKCS#12 file, not both")
+	}
+
+	var certificate *tls.Certificate
+	var loadErr error
+	if options.PKCS12 != "" {
+		certificate, loadErr = loadPKCS12(options.PKCS12, options.Password)
+	} else {
+		certificate, loadErr = loadPEM(options.Cert, options.Key)
+	}
+
+	if loadErr != nil {
+		return nil, loadErr
+	}
+
+	config.Certificates = []tls.Certificate{*certificate}
+	return config, nil
+}
+
+func loadCAFile(caFile string) (*x509.CertPool, error) {
+	data, readErr := ioutil.ReadFile(caFile)
+	if readErr != nil {
+		return nil, fmt.Errorf("Error while reading CA file %s: %s", caFile, readErr)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("No PEM certificates found in CA file %s", caFile)
+	}
+
+	return pool, nil
+}
+
+func parseVersion(version string) (uint16, error) {
+	if version == "" {
+		return 0, nil
+	}
+
+	parsed, exists := tlsVersions[version]
+	if !exists {
+		return 0, fmt.Errorf("Unsupported TLS version '%s', must be one of: 1.0, 1.1, 1.2, 1.3", version)
+	}
+
+	return parsed, nil
+}
+
+func resolvePath(dir string, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
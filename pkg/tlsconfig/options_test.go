@@ -0,0 +1,137 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptions(t *testing.T) {
+	t.Run("Merges client certificate as a whole", testMergesClientCertificate)
+	t.Run("Resolves relative paths", testResolvesRelativePaths)
+	t.Run("Loads PEM client certificate", testLoadsPEMClientCertificate)
+	t.Run("Loads PKCS#12 client certificate", testLoadsPKCS12ClientCertificate)
+	t.Run("Fails with PEM and PKCS#12 configured", testFailsWithPEMAndPKCS12)
+	t.Run("Trusts certificates from CA file", testTrustsCAFile)
+	t.Run("Sets TLS version bounds", testSetsTLSVersionBounds)
+}
+
+func testMergesClientCertificate(t *testing.T) {
+	fromProfile := Options{PKCS12: "profile.p12", Password: "secret"}
+
+	assert.Equal(t, fromProfile, fromProfile.Merge(Options{}), "Should keep certificate if nothing to merge")
+	assert.Equal(t, Options{Cert: "cli.crt", Key: "cli.key"}, fromProfile.Merge(Options{Cert: "cli.crt", Key: "cli.key"}), "Should replace certificate")
+}
+
+func testResolvesRelativePaths(t *testing.T) {
+	resolved := Options{Cert: "client.crt", Key: "/etc/client.key"}.ResolvePaths("/profiles")
+
+	assert.Equal(t, "/profiles/client.crt", resolved.Cert, "Should resolve relative path")
+	assert.Equal(t, "/etc/client.key", resolved.Key, "Should keep absolute path")
+	assert.Equal(t, "", resolved.PKCS12, "Should keep empty path")
+}
+
+func testLoadsPEMClientCertificate(t *testing.T) {
+	dir, dirErr := ioutil.TempDir("", "certificates")
+	require.Nil(t, dirErr)
+	defer os.RemoveAll(dir)
+
+	certFile, keyFile := CreateTestCertificate(dir, "client")
+
+	config, err := Options{Cert: certFile, Key: keyFile}.ToConfig(true)
+	require.Nil(t, err, "Should load certificate")
+	assert.True(t, config.InsecureSkipVerify, "Should allow insecure")
+	assert.Equal(t, "client", parseLeaf(t, config.Certificates[0].Certificate[0]).Subject.CommonName)
+
+	_, err = Options{Cert: certFile}.ToConfig(false)
+	assert.NotNil(t, err, "Should look for the key in the certificate file")
+}
+
+func testLoadsPKCS12ClientCertificate(t *testing.T) {
+	config, err := Options{PKCS12: "testdata/client.p12", Password: "secret"}.ToConfig(false)
+	require.Nil(t, err, "Should load certificate")
+	assert.Equal(t, "pkcs12-client", parseLeaf(t, config.Certificates[0].Certificate[0]).Subject.CommonName)
+
+	_, err = Options{PKCS12: "testdata/client.p12", Password: "wrong"}.ToConfig(false)
+	assert.NotNil(t, err, "Should fail with wrong password")
+}
+
+func testFailsWithPEMAndPKCS12(t *testing.T) {
+	_, err := Options{Cert: "client.crt", PKCS12: "client.p12"}.ToConfig(false)
+	assert.NotNil(t, err, "Should not accept both")
+}
+
+func testTrustsCAFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	_, err := requestWithOptions(server.URL, Options{})
+	require.NotNil(t, err, "Should not trust server certificate by default")
+	assert.Equal(t, "certificate authority", err.(VerificationError).Check, "Should say which check failed")
+
+	dir, dirErr := ioutil.TempDir("", "certificates")
+	require.Nil(t, dirErr)
+	defer os.RemoveAll(dir)
+
+	caFile := filepath.Join(dir, "ca.crt")
+	writePEM(caFile, "CERTIFICATE", server.Certificate().Raw)
+
+	_, err = requestWithOptions(server.URL, Options{CAFile: caFile})
+	assert.Nil(t, err, "Should trust server certificate from CA file")
+
+	_, err = Options{CAFile: "testdata/client.p12"}.ToConfig(false)
+	assert.NotNil(t, err, "Should fail if no certificates in CA file")
+}
+
+func testSetsTLSVersionBounds(t *testing.T) {
+	config, err := Options{MinVersion: "1.2", MaxVersion: "1.3"}.ToConfig(false)
+	require.Nil(t, err, "Should accept versions")
+	assert.Equal(t, uint16(tls.VersionTLS12), config.MinVersion)
+	assert.Equal(t, uint16(tls.VersionTLS13), config.MaxVersion)
+
+	_, err = Options{MinVersion: "1.3", MaxVersion: "1.2"}.ToConfig(false)
+	assert.NotNil(t, err, "Should not accept minimum greater than maximum")
+
+	_, err = Options{MinVersion: "2"}.ToConfig(false)
+	assert.NotNil(t, err, "Should not accept unknown version")
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{MaxVersion: tls.VersionTLS12}
+	server.StartTLS()
+	defer server.Close()
+
+	_, err = requestWithOptions(server.URL, Options{MinVersion: "1.3"})
+	require.NotNil(t, err, "Should not connect with older TLS version")
+	assert.Equal(t, "protocol version", err.(VerificationError).Check, "Should say which check failed")
+}
+
+func parseLeaf(t *testing.T, der []byte) *x509.Certificate {
+	certificate, err := x509.ParseCertificate(der)
+	require.Nil(t, err)
+	return certificate
+}
+
+// requestWithOptions executes a request using the TLS configuration from the options
+func requestWithOptions(url string, options Options) (*http.Response, error) {
+	config, configErr := options.ToConfig(false)
+	if configErr != nil {
+		return nil, configErr
+	}
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	response, err := client.Get(url)
+	if err != nil {
+		return nil, DescribeError(err)
+	}
+
+	response.Body.Close()
+	return response, nil
+}
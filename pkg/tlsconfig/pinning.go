@@ -0,0 +1,53 @@
+package tlsconfig
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+const pinPrefix = "sha256//"
+
+// PublicKeyPin calculates the pin for the certificate public key: the base64 encoded SHA-256 hash
+// of the DER encoded subject public key info
+func PublicKeyPin(certificate *x509.Certificate) string {
+	hash := sha256.Sum256(certificate.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// createPinVerifier creates a function that checks that the server certificate, or one of the
+// certificates in the chains it was verified with, has one of the pinned public keys. Without
+// verification, only the server certificate is checked, anyone can append other certificates to the
+// ones they send. Pins can be prefixed with "sha256//" like in curl.
+func createPinVerifier(pinnedPublicKeys []string) (func([][]byte, [][]*x509.Certificate) error, error) {
+	pins := make(map[string]bool)
+	for _, pin := range pinnedPublicKeys {
+		pin = strings.TrimPrefix(strings.TrimSpace(pin), pinPrefix)
+		decoded, decodeErr := base64.StdEncoding.DecodeString(pin)
+		if decodeErr != nil || len(decoded) != sha256.Size {
+			return nil, fmt.Errorf("Invalid pinned public key '%s', must be a base64 encoded SHA-256 hash", pin)
+		}
+		pins[pin] = true
+	}
+
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		for _, chain := range verifiedChains {
+			for _, certificate := range chain {
+				if pins[PublicKeyPin(certificate)] {
+					return nil
+				}
+			}
+		}
+
+		if len(verifiedChains) == 0 && len(rawCerts) > 0 {
+			certificate, parseErr := x509.ParseCertificate(rawCerts[0])
+			if parseErr == nil && pins[PublicKeyPin(certificate)] {
+				return nil
+			}
+		}
+
+		return PinningError{}
+	}, nil
+}
@@ -0,0 +1,86 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPinning(t *testing.T) {
+	t.Run("Accepts pinned public key", testAcceptsPinnedPublicKey)
+	t.Run("Rejects unknown public key", testRejectsUnknownPublicKey)
+	t.Run("Rejects pinned public key after server certificate", testRejectsPinnedPublicKeyAfterServerCertificate)
+	t.Run("Rejects invalid pins", testRejectsInvalidPins)
+}
+
+func testAcceptsPinnedPublicKey(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	pin := PublicKeyPin(server.Certificate())
+
+	config, configErr := Options{PinnedPublicKeys: []string{"sha256//" + pin}}.ToConfig(true)
+	require.Nil(t, configErr)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	response, err := client.Get(server.URL)
+	require.Nil(t, err, "Should accept pinned public key even when insecure")
+	response.Body.Close()
+}
+
+func testRejectsUnknownPublicKey(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	config, configErr := Options{PinnedPublicKeys: []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}}.ToConfig(true)
+	require.Nil(t, configErr)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	_, err := client.Get(server.URL)
+	require.NotNil(t, err, "Should reject connection")
+
+	describedErr := DescribeError(err)
+	assert.Equal(t, "public key pinning", describedErr.(VerificationError).Check, "Should say which check failed")
+}
+
+func testRejectsPinnedPublicKeyAfterServerCertificate(t *testing.T) {
+	dir, dirErr := ioutil.TempDir("", "pinning")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	serverCert, serverKey := CreateTestCertificate(dir, "server")
+	serverCertificate, serverErr := tls.LoadX509KeyPair(serverCert, serverKey)
+	require.Nil(t, serverErr, "Should load server certificate")
+
+	pinnedCert, pinnedKey := CreateTestCertificate(dir, "pinned")
+	pinnedCertificate, pinnedErr := tls.LoadX509KeyPair(pinnedCert, pinnedKey)
+	require.Nil(t, pinnedErr, "Should load pinned certificate")
+	parsedPinned, parseErr := x509.ParseCertificate(pinnedCertificate.Certificate[0])
+	require.Nil(t, parseErr, "Should parse pinned certificate")
+
+	// Anyone can send a certificate with the pinned public key after their own
+	serverCertificate.Certificate = append(serverCertificate.Certificate, pinnedCertificate.Certificate[0])
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{serverCertificate}}
+	server.StartTLS()
+	defer server.Close()
+
+	config, configErr := Options{PinnedPublicKeys: []string{PublicKeyPin(parsedPinned)}}.ToConfig(true)
+	require.Nil(t, configErr)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: config}}
+	_, err := client.Get(server.URL)
+	assert.NotNil(t, err, "Should only check the server certificate")
+}
+
+func testRejectsInvalidPins(t *testing.T) {
+	_, err := Options{PinnedPublicKeys: []string{"not-a-hash"}}.ToConfig(false)
+	assert.NotNil(t, err, "Should not accept invalid pin")
+}
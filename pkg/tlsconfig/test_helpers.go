@@ -0,0 +1,55 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"time"
+)
+
+// CreateTestCertificate helper method for testing. Generates a self-signed certificate and writes
+// the certificate and the private key as PEM files in the directory with the passed in name.
+func CreateTestCertificate(dir string, name string) (string, string) {
+	privateKey, keyErr := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if keyErr != nil {
+		panic(keyErr)
+	}
+
+	template := &x509.Certificate{
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		NotAfter:     time.Now().Add(time.Hour),
+		NotBefore:    time.Now().Add(-time.Hour),
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+	}
+
+	certDER, certErr := x509.CreateCertificate(rand.Reader, template, template, &privateKey.PublicKey, privateKey)
+	if certErr != nil {
+		panic(certErr)
+	}
+
+	keyDER, marshalErr := x509.MarshalECPrivateKey(privateKey)
+	if marshalErr != nil {
+		panic(marshalErr)
+	}
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	writePEM(certFile, "CERTIFICATE", certDER)
+	writePEM(keyFile, "EC PRIVATE KEY", keyDER)
+
+	return certFile, keyFile
+}
+
+func writePEM(file string, blockType string, data []byte) {
+	if err := ioutil.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: data}), 0600); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,44 @@
+//go:build !windows
+// +build !windows
+
+package upload
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// PipeStdin makes the standard input available to the daemon as a file. It creates a named pipe
+// that the standard input is copied to in the background, so that it's streamed without being
+// stored anywhere. The pipe is removed once it's opened, so it can only be sent once. The returned
+// function removes the pipe if it was never opened.
+func PipeStdin() (string, func(), error) {
+	dir, dirErr := ioutil.TempDir("", "go-http-cli-stdin")
+	if dirErr != nil {
+		return "", nil, fmt.Errorf("Error while creating pipe for standard input: %s", dirErr)
+	}
+	remove := func() { os.RemoveAll(dir) }
+
+	pipe := filepath.Join(dir, "stdin")
+	if fifoErr := syscall.Mkfifo(pipe, 0600); fifoErr != nil {
+		remove()
+		return "", nil, fmt.Errorf("Error while creating pipe for standard input: %s", fifoErr)
+	}
+
+	go func() {
+		// Blocks until the daemon opens the pipe to read from it
+		writer, openErr := os.OpenFile(pipe, os.O_WRONLY, 0)
+		remove()
+		if openErr != nil {
+			return
+		}
+		defer writer.Close()
+		io.Copy(writer, os.Stdin)
+	}()
+
+	return pipe, remove, nil
+}
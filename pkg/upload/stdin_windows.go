@@ -0,0 +1,28 @@
+package upload
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// PipeStdin makes the standard input available to the daemon as a file. Named pipes can't be
+// created like files on Windows, so the standard input is copied to a temporary file instead. The
+// returned function removes the file.
+func PipeStdin() (string, func(), error) {
+	file, createErr := ioutil.TempFile("", "go-http-cli-stdin")
+	if createErr != nil {
+		return "", nil, fmt.Errorf("Error while copying standard input: %s", createErr)
+	}
+	defer file.Close()
+	remove := func() { os.Remove(file.Name()) }
+
+	if _, copyErr := io.Copy(file, os.Stdin); copyErr != nil {
+		file.Close()
+		remove()
+		return "", nil, fmt.Errorf("Error while copying standard input: %s", copyErr)
+	}
+
+	return file.Name(), remove, nil
+}
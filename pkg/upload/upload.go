@@ -0,0 +1,55 @@
+package upload
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/visola/go-http-cli/pkg/progress"
+)
+
+// Stdin is what's passed as the file to upload to send the standard input
+const Stdin = "-"
+
+// Open opens a file to be streamed as a request body. Returns the size of the file or -1 if it's not
+// a regular file, like a pipe, in which case the size is only known after reading all of it.
+func Open(file string) (io.ReadCloser, int64, error) {
+	opened, openErr := os.Open(file)
+	if openErr != nil {
+		return nil, 0, fmt.Errorf("Error while opening file to upload: %s", openErr)
+	}
+
+	info, statErr := opened.Stat()
+	if statErr != nil {
+		opened.Close()
+		return nil, 0, fmt.Errorf("Error while opening file to upload: %s", statErr)
+	}
+
+	if !info.Mode().IsRegular() {
+		return opened, -1, nil
+	}
+	return opened, info.Size(), nil
+}
+
+// WithProgress wraps a body so that the progress is reported while it's read
+func WithProgress(body io.ReadCloser, file string, total int64, onProgress func(progress.Progress)) io.ReadCloser {
+	return &progressReader{
+		ReadCloser: body,
+		reporter:   progress.NewReporter(progress.Progress{File: file, Total: total, Upload: true}, onProgress),
+	}
+}
+
+// progressReader reports what's read from the body until it reaches the end
+type progressReader struct {
+	io.ReadCloser
+	reporter *progress.Reporter
+}
+
+func (reader *progressReader) Read(data []byte) (int, error) {
+	read, readErr := reader.ReadCloser.Read(data)
+	reader.reporter.Write(data[:read])
+	if readErr == io.EOF {
+		reader.reporter.Done()
+	}
+	return read, readErr
+}
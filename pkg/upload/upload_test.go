@@ -0,0 +1,60 @@
+package upload
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/visola/go-http-cli/pkg/progress"
+)
+
+func TestOpen(t *testing.T) {
+	t.Run("Returns size of regular file", testReturnsSizeOfRegularFile)
+	t.Run("Fails if file doesn't exist", testFailsIfFileDoesNotExist)
+}
+
+func TestWithProgress(t *testing.T) {
+	dir, dirErr := ioutil.TempDir("", "upload")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "dump.sql")
+	require.Nil(t, ioutil.WriteFile(file, []byte("INSERT INTO dumps VALUES (1);\n"), 0600), "Should write file")
+
+	body, size, openErr := Open(file)
+	require.Nil(t, openErr, "Should open file")
+
+	reported := make([]progress.Progress, 0)
+	read, readErr := ioutil.ReadAll(WithProgress(body, file, size, func(transferred progress.Progress) {
+		reported = append(reported, transferred)
+	}))
+	require.Nil(t, readErr, "Should read body")
+	body.Close()
+
+	assert.Equal(t, "INSERT INTO dumps VALUES (1);\n", string(read), "Should read file as it is")
+	require.True(t, len(reported) > 0, "Should report progress")
+	assert.Equal(t, progress.Progress{Done: true, File: file, Total: size, Transferred: size, Upload: true}, reported[len(reported)-1], "Should report when done")
+}
+
+func testReturnsSizeOfRegularFile(t *testing.T) {
+	dir, dirErr := ioutil.TempDir("", "upload")
+	require.Nil(t, dirErr, "Should create temp dir")
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "dump.sql")
+	require.Nil(t, ioutil.WriteFile(file, make([]byte, 1234), 0600), "Should write file")
+
+	body, size, err := Open(file)
+	require.Nil(t, err, "Should open file")
+	defer body.Close()
+
+	assert.Equal(t, int64(1234), size, "Should return size of file")
+}
+
+func testFailsIfFileDoesNotExist(t *testing.T) {
+	_, _, err := Open(filepath.Join(os.TempDir(), "go-http-cli-missing", "dump.sql"))
+	assert.NotNil(t, err, "Should fail to open missing file")
+}
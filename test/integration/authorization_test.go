@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"net/http"
+	"os"
+	"testing"
+)
+
+func TestAuthorization(t *testing.T) {
+	t.Run("OAuth2 client credentials", WrapForIntegrationTest(testOAuth2ClientCredentials))
+	t.Run("Login and replay on unauthorized", WrapForIntegrationTest(testLoginAndReplayOnUnauthorized))
+	t.Run("Post process replayed request", WrapForIntegrationTest(testPostProcessReplayedRequest))
+}
+
+func testOAuth2ClientCredentials(t *testing.T) {
+	CreateProfile("oauth2", `
+baseURL: '{test-server}'
+
+auth:
+  type: oauth2-client-credentials
+  tokenURL: '{test-server}/token'
+  clientId: my-client
+  clientSecret: my-secret
+  scopes:
+    - read
+`)
+
+	prepareReply(ReplyWith{
+		Body: `{"access_token":"machine-token","expires_in":3600}`,
+	})
+
+	RunHTTP(t, "+oauth2", "/companies")
+	RunHTTP(t, "+oauth2", "/employees")
+
+	// Token is cached in the daemon, so only one token request
+	HasRequestCount(t, 3)
+	HasPath(t, allRequests[0], "/token")
+	HasBody(t, allRequests[0], "grant_type=client_credentials&scope=read")
+	HasPath(t, allRequests[1], "/companies")
+	HasHeader(t, allRequests[1], "Authorization", "Bearer machine-token")
+	HasPath(t, allRequests[2], "/employees")
+	HasHeader(t, allRequests[2], "Authorization", "Bearer machine-token")
+}
+
+func testLoginAndReplayOnUnauthorized(t *testing.T) {
+	CreateProfile("refresh", `
+baseURL: '{test-server}'
+
+headers:
+  Authorization: Bearer {token}
+
+onUnauthorized: login
+
+requests:
+  login:
+    url: /login
+    method: POST
+    postProcessScript: |
+      addVariable('token', 'fresh-token');
+`)
+
+	prepareReply(ReplyWith{StatusCode: http.StatusUnauthorized})
+
+	RunHTTP(t, "+refresh", "/dashboard")
+
+	HasRequestCount(t, 3)
+	HasPath(t, allRequests[0], "/dashboard")
+	HasHeader(t, allRequests[0], "Authorization", "Bearer {token}")
+	HasPath(t, allRequests[1], "/login")
+	HasPath(t, allRequests[2], "/dashboard")
+	HasHeader(t, allRequests[2], "Authorization", "Bearer fresh-token")
+}
+
+func testPostProcessReplayedRequest(t *testing.T) {
+	CreateProfile("refresh", `
+baseURL: '{test-server}'
+
+onUnauthorized: login
+
+requests:
+  login:
+    url: /login
+    method: POST
+`)
+
+	postProcessScript := `
+		addRequest('/replayed/' + response.StatusCode + '/first/' + executed[0].Response.StatusCode);
+	`
+
+	WithTempFile(t, postProcessScript, func(tempFile *os.File) {
+		prepareReply(ReplyWith{StatusCode: http.StatusUnauthorized})
+
+		RunHTTP(t, "+refresh", "--post-process", tempFile.Name(), "/dashboard")
+
+		HasRequestCount(t, 4)
+		HasPath(t, allRequests[3], "/replayed/200/first/401")
+	})
+}
@@ -10,6 +10,7 @@ import (
 
 func TestOutput(t *testing.T) {
 	t.Run("Replace variables on output", WrapForIntegrationTest(testVariablesGetReplacedOnOutput))
+	t.Run("Show timings", WrapForIntegrationTest(testShowsTimings))
 }
 
 func testVariablesGetReplacedOnOutput(t *testing.T) {
@@ -26,3 +27,12 @@ func testVariablesGetReplacedOnOutput(t *testing.T) {
 	assert.Equal(t, expectedFirstLine, lines[0], "Should replace variables on output")
 	assert.Equal(t, "200 OK 1.1", lines[2], "Third line should show status")
 }
+
+func testShowsTimings(t *testing.T) {
+	output := RunHTTP(t, "--timings", testServer.URL+"/hello")
+
+	assert.Contains(t, output, "Timings:", "Should show timings")
+	for _, phase := range []string{"DNS lookup:", "Connect:", "TLS handshake:", "First byte:", "Transfer:", "Total:"} {
+		assert.Contains(t, output, phase, "Should show how long %s took", phase)
+	}
+}
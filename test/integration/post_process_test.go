@@ -11,6 +11,7 @@ func TestPostProcess(t *testing.T) {
 	t.Run("Add request by name", WrapForIntegrationTest(testAddRequestByNameFromScript))
 	t.Run("Add request by URL", WrapForIntegrationTest(testAddRequestByURLFromScript))
 	t.Run("Set variable", WrapForIntegrationTest(testSetVariableFromScript))
+	t.Run("Read timings", WrapForIntegrationTest(testReadTimingsFromScript))
 }
 
 func testAddRequestAsObjectFromScript(t *testing.T) {
@@ -103,3 +104,22 @@ func testSetVariableFromScript(t *testing.T) {
 		HasHeader(t, lastRequest, "Authorization", "Bearer "+token)
 	})
 }
+
+func testReadTimingsFromScript(t *testing.T) {
+	CreateProfile("test", `
+baseURL: '{test-server}'
+`)
+
+	postProcessScript := `
+		if (response.timings.total > 0 && response.timings.connect > 0) {
+			addRequest('/timed');
+		}
+	`
+
+	WithTempFile(t, postProcessScript, func (tempFile *os.File) {
+		RunHTTP(t, "+test", "--post-process", tempFile.Name(), testServer.URL+"/hello")
+
+		HasRequestCount(t, 2)
+		HasPath(t, allRequests[1], "/timed")
+	})
+}
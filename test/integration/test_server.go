@@ -20,8 +20,9 @@ type Request struct {
 
 // ReplyWith gives specifications the ability to ask the server to reply in a specific way
 type ReplyWith struct {
-	Headers map[string][]string
-	Body string
+	Headers    map[string][]string
+	Body       string
+	StatusCode int
 }
 
 const defaultBody = "Hello world!"
@@ -60,6 +61,10 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if replyWith.StatusCode != 0 {
+		w.WriteHeader(replyWith.StatusCode)
+	}
+
 	// TODO - Store request received
 	fmt.Fprintln(w, replyWith.Body);
 